@@ -0,0 +1,153 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/elboletaire/manga-downloader/browser"
+	"github.com/elboletaire/manga-downloader/grabber"
+	"github.com/elboletaire/manga-downloader/ranges"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// inputFile is the path of the batch file given with --input-file
+var inputFile string
+
+// batchLine is a series to download from the batch input file, one per line:
+//
+//	<url> <ranges> [--language xx] [--scanlator yy]
+type batchLine struct {
+	// Line is the line number in the file, for error messages
+	Line  int
+	URL   string
+	Range string
+	// Language and Scanlator override the command flags when set
+	Language  string
+	Scanlator string
+}
+
+// apply sets the line's own settings on cfg
+func (b batchLine) apply(cfg *grabber.Settings) {
+	if b.Language != "" {
+		cfg.Language = b.Language
+	}
+	if b.Scanlator != "" {
+		cfg.Scanlator = b.Scanlator
+	}
+}
+
+// parseBatchFile parses a batch input file. Blank lines and lines starting
+// with # are ignored; any malformed line fails the whole file, so a typo is
+// found before anything is downloaded rather than halfway through.
+func parseBatchFile(r io.Reader) ([]batchLine, error) {
+	lines := []batchLine{}
+	scanner := bufio.NewScanner(r)
+	n := 0
+	for scanner.Scan() {
+		n++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		line, err := parseBatchLine(n, strings.Fields(text))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+// parseBatchLine parses the fields of a single batch line
+func parseBatchLine(n int, fields []string) (batchLine, error) {
+	line := batchLine{Line: n}
+	if len(fields) < 2 {
+		return line, fmt.Errorf("expected <url> <ranges>, got %q", strings.Join(fields, " "))
+	}
+	if !strings.HasPrefix(fields[0], "http") {
+		return line, fmt.Errorf("invalid url %q", fields[0])
+	}
+	line.URL = fields[0]
+	line.Range = fields[1]
+	if _, err := ranges.Parse(line.Range); err != nil {
+		return line, fmt.Errorf("invalid ranges %q: %w", line.Range, err)
+	}
+
+	for i := 2; i < len(fields); i++ {
+		name, value, inline := strings.Cut(fields[i], "=")
+		if !inline {
+			if i+1 >= len(fields) {
+				return line, fmt.Errorf("missing value for %s", name)
+			}
+			i++
+			value = fields[i]
+		}
+
+		switch name {
+		case "--language", "-l":
+			line.Language = value
+		case "--scanlator", "-s":
+			line.Scanlator = value
+		default:
+			return line, fmt.Errorf("unknown option %q (only --language and --scanlator are allowed)", name)
+		}
+	}
+
+	return line, nil
+}
+
+// runBatch downloads every series of the --input-file, one after the other
+// in this same process, so the shared browser and the session cookies it
+// harvested are reused instead of facing a new challenge per series. It ends
+// with a report of how each line went.
+func runBatch(cmd *cobra.Command) {
+	defer browser.Close()
+	browser.SetVisible(settings.BrowserVisible)
+
+	cerr(validateSettings(&settings), "Error: ")
+	cerr(readPageOptions(cmd), "Error: ")
+
+	f, err := os.Open(inputFile)
+	cerr(err, "Error reading the input file: ")
+	lines, err := parseBatchFile(f)
+	f.Close()
+	cerr(err, "Error parsing the input file: ")
+
+	if len(lines) == 0 {
+		color.Yellow("No series found in %s", inputFile)
+		exit(0)
+	}
+
+	summaries := []seriesSummary{}
+	lgrs := ledgers{}
+	for _, line := range lines {
+		fmt.Printf("- %s %s %s\n", color.GreenString("downloading"), color.HiBlackString(line.URL), line.Range)
+		// every line goes into the same --output-dir
+		summaries = append(summaries, downloadBatchLine(cmd, line, lgrs, len(lines) > 1))
+	}
+
+	printSummaries("Batch summary:", summaries)
+}
+
+// downloadBatchLine downloads the chapters of a single batch line, taking the
+// ledger of its output dir from lgrs. shared tells whether other lines are
+// saved into the same dir (see seriesJob.shared).
+func downloadBatchLine(cmd *cobra.Command, line batchLine, lgrs ledgers, shared bool) seriesSummary {
+	job, sum := prepareSeries(cmd, line.URL, line.Range, line.apply, lgrs)
+	if job != nil {
+		job.shared = shared
+		d := newDownloadSession(job.site.GetMaxConcurrency().Chapters)
+		sum.Result, sum.Err = job.download(d)
+		d.wait()
+	}
+
+	return sum
+}
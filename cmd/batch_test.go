@@ -0,0 +1,66 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elboletaire/manga-downloader/grabber"
+)
+
+func TestParseBatchFile(t *testing.T) {
+	input := `# weekly reads
+https://example.com/a 1-10
+
+https://example.com/b 5,7-9 --language es --scanlator=Alpha
+https://example.com/c 1 -l fr
+`
+	lines, err := parseBatchFile(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []batchLine{
+		{Line: 2, URL: "https://example.com/a", Range: "1-10"},
+		{Line: 4, URL: "https://example.com/b", Range: "5,7-9", Language: "es", Scanlator: "Alpha"},
+		{Line: 5, URL: "https://example.com/c", Range: "1", Language: "fr"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got %+v", len(want), lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d: expected %+v, got %+v", i, want[i], lines[i])
+		}
+	}
+}
+
+func TestParseBatchFileErrors(t *testing.T) {
+	cases := map[string]string{
+		"https://example.com/a":                    "line 1: expected <url> <ranges>",
+		"example.com/a 1-10":                       "line 1: invalid url",
+		"https://example.com/a x-y":                "line 1: invalid ranges",
+		"https://example.com/a 1 --bundle":         "line 1: missing value",
+		"https://example.com/a 1 --format raw":     "line 1: unknown option",
+		"# ok\nhttps://example.com/a 1 --language": "line 2: missing value",
+	}
+
+	for input, want := range cases {
+		if _, err := parseBatchFile(strings.NewReader(input)); err == nil || !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("%q: expected an error starting with %q, got: %v", input, want, err)
+		}
+	}
+}
+
+func TestBatchLineApply(t *testing.T) {
+	cfg := grabber.Settings{Language: "en", Scanlator: "Beta"}
+	batchLine{Range: "1-3", Language: "es"}.apply(&cfg)
+
+	if cfg.Language != "es" {
+		t.Errorf("expected the line settings to apply, got %+v", cfg)
+	}
+	if cfg.Scanlator != "Beta" {
+		t.Errorf("an empty scanlator must keep the flag's, got %q", cfg.Scanlator)
+	}
+}
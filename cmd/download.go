@@ -0,0 +1,687 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/elboletaire/manga-downloader/downloader"
+	"github.com/elboletaire/manga-downloader/grabber"
+	"github.com/elboletaire/manga-downloader/http"
+	"github.com/elboletaire/manga-downloader/ledger"
+	"github.com/elboletaire/manga-downloader/packer"
+	"github.com/elboletaire/manga-downloader/packer/pageopts"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/vbauerster/mpb/v8"
+	"github.com/vbauerster/mpb/v8/decor"
+)
+
+// downloadResult summarises a downloadChapters run
+type downloadResult struct {
+	// Files are the saved files, relative to the output dir (a single one
+	// when bundling)
+	Files []string
+	// Chapters are the chapters saved, in the order they finished
+	Chapters []*grabber.Chapter
+	// Failed is the number of chapters that couldn't be downloaded or saved
+	Failed int
+	// Cover is the series cover to save as files (--cover file or both),
+	// once the chapters are (see saveCoverFiles)
+	Cover []byte
+}
+
+// validateSettings checks the settings the sites themselves don't validate.
+// It's done here (and only here) so Grabber.GetConvertImages can ignore the
+// parse error later, the same way maxUint8Flag ignores its own.
+func validateSettings(cfg *grabber.Settings) error {
+	if _, ok := packer.LookupFormat(cfg.Format); !ok {
+		return fmt.Errorf("invalid format %q, must be one of: %s", cfg.Format, strings.Join(packer.FormatNames(), ", "))
+	}
+
+	if !slices.Contains(packer.Directions, cfg.ReadingDirection) {
+		return fmt.Errorf("invalid --reading-direction value %q, must be one of: %s", cfg.ReadingDirection, strings.Join(packer.Directions, ", "))
+	}
+
+	if !slices.Contains(packer.BundleByModes, cfg.BundleBy) {
+		return fmt.Errorf("invalid --bundle-by value %q, must be one of: %s", cfg.BundleBy, strings.Join(packer.BundleByModes, ", "))
+	}
+	if cfg.BundleBy == packer.BundleByVolume && !cfg.Bundle {
+		return errors.New("--bundle-by volume splits bundles, it only applies along with --bundle")
+	}
+	if _, err := bundleLimits(cfg); err != nil {
+		return err
+	}
+	if (cfg.BundleMaxPages > 0 || cfg.BundleMaxSize != "") && !cfg.Bundle {
+		return errors.New("--bundle-max-pages and --bundle-max-size split bundles, they only apply along with --bundle")
+	}
+
+	if !slices.Contains(packer.CoverModes, cfg.Cover) {
+		return fmt.Errorf("invalid --cover value %q, must be one of: %s", cfg.Cover, strings.Join(packer.CoverModes, ", "))
+	}
+	if cfg.Cover == packer.CoverBundle && !cfg.Bundle {
+		return errors.New("--cover bundle prepends the cover to bundles, it only applies along with --bundle")
+	}
+
+	if cfg.Upgrade && cfg.Bundle {
+		return errors.New("--upgrade replaces single chapter files, it can't be used along with --bundle")
+	}
+
+	if !slices.Contains(packer.OnExistsPolicies, cfg.OnExists) {
+		return fmt.Errorf("invalid --on-exists value %q, must be one of: %s", cfg.OnExists, strings.Join(packer.OnExistsPolicies, ", "))
+	}
+
+	if _, err := grabber.ParseConvertFormats(cfg.ConvertImages); err != nil {
+		return fmt.Errorf("invalid --convert-images value: %s", err)
+	}
+
+	return nil
+}
+
+// readPageOptions reads the page options from the command flags into
+// pageOptions, for the packer to rework the pages with. It's done once, at
+// startup, so an invalid flag stops the command before anything's
+// downloaded.
+func readPageOptions(cmd *cobra.Command) error {
+	var err error
+	pageOptions, err = pageopts.FromFlags(cmd.Flags())
+
+	return err
+}
+
+// newSite identifies the site url belongs to and initializes it from the
+// command flags. The errors hit while testing the sites are only printed: a
+// site being down shouldn't stop the one that matches from working.
+func newSite(url string, cfg *grabber.Settings, cmd *cobra.Command) (grabber.Site, error) {
+	s, errs := grabber.NewSite(url, cfg)
+	if len(errs) > 0 {
+		color.Red("Errors testing site (a site may be down):")
+		for _, err := range errs {
+			color.Red(err.Error())
+		}
+	}
+	if s == nil {
+		return nil, errors.New("Site not recognised")
+	}
+	s.InitFlags(cmd)
+
+	return s, nil
+}
+
+// fetchChapterList fetches every chapter of the site's series, sorted by
+// number. An empty list is an error too: it usually means the language filter
+// matched nothing (e.g. an invalid language code), and every caller would
+// otherwise have to guard against indexing it.
+func fetchChapterList(s grabber.Site, cfg *grabber.Settings) (grabber.Filterables, error) {
+	chapters, errs := s.FetchChapters()
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("Errors fetching chapters:\n%w", errors.Join(errs...))
+	}
+
+	chapters = chapters.SortByNumber()
+	if len(chapters) == 0 {
+		return nil, errors.New(noChaptersMessage(cfg.Language, cfg.Scanlator))
+	}
+
+	return chapters, nil
+}
+
+// pendingChapters leaves out of chapters the ones the ledger says are saved
+// already (unless cfg.Force), returning how many it left out. With
+// cfg.Upgrade, the saved chapters the site changed since are kept, and the
+// entries recording them returned, for downloadChapters to replace their files.
+func pendingChapters(s grabber.Site, cfg *grabber.Settings, url string, chapters grabber.Filterables, lgr *ledger.Ledger) (pending grabber.Filterables, upgrades []ledger.Entry, skipped int) {
+	if cfg.Force {
+		return chapters, nil, 0
+	}
+
+	pending, saved := lgr.Pending(url, chapters)
+	if cfg.Upgrade && len(saved) > 0 {
+		color.HiBlack("- checking %d chapters already downloaded for changes", len(saved))
+		var outdated grabber.Filterables
+		outdated, upgrades = outdatedChapters(s, url, saved, lgr)
+		pending = append(pending, outdated...).SortByNumber()
+	}
+
+	return pending, upgrades, len(saved) - len(upgrades)
+}
+
+// outdatedChapters fetches the given saved chapters again, returning the ones
+// the site changed since they were saved, along with the entries recording
+// them. Chapters saved into a bundle are left alone: replacing the bundle with
+// a single chapter would lose the others.
+func outdatedChapters(s grabber.Site, url string, saved grabber.Filterables, lgr *ledger.Ledger) (outdated grabber.Filterables, entries []ledger.Entry) {
+	files := map[string]int{}
+	for _, e := range lgr.Entries(url) {
+		files[e.Filename]++
+	}
+
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	g := make(chan struct{}, s.GetMaxConcurrency().Chapters)
+	for _, chap := range saved {
+		g <- struct{}{}
+		wg.Add(1)
+
+		go func(chap grabber.Filterable) {
+			defer wg.Done()
+			defer func() { <-g }()
+
+			chapter, err := s.FetchChapter(chap)
+			if err != nil {
+				color.Red("- error checking chapter %s for changes: %s", chap.GetTitle(), err.Error())
+				return
+			}
+			entry, ok := lgr.Find(url, chapter)
+			if !ok || files[entry.Filename] > 1 || !entry.Outdated(chapter) {
+				return
+			}
+
+			color.Yellow("- chapter %g changed on the site (%d pages, saved from %d), downloading it again", chapter.GetNumber(), chapter.PagesCount, entry.SourcePages)
+			mu.Lock()
+			outdated = append(outdated, chap)
+			entries = append(entries, entry)
+			mu.Unlock()
+		}(chap)
+	}
+	wg.Wait()
+
+	return outdated, entries
+}
+
+// downloadSession is the progress container and the chapter concurrency
+// budget shared by every series downloaded at once, so several series show
+// their bars together and don't download more chapters at once than one would
+type downloadSession struct {
+	p *mpb.Progress
+	// chapters bounds the chapters downloaded at once, across every series
+	chapters chan struct{}
+}
+
+// newDownloadSession returns a session downloading up to maxChapters at once
+func newDownloadSession(maxChapters uint8) *downloadSession {
+	return &downloadSession{
+		p: mpb.New(
+			mpb.WithWidth(40),
+			mpb.WithOutput(color.Output),
+			mpb.WithAutoRefresh(),
+		),
+		chapters: make(chan struct{}, max(maxChapters, 1)),
+	}
+}
+
+// wait lets the render loop paint the final state of the bars once every
+// series is done; without this, fast packing (e.g. raw folders) ends with the
+// last painted frame stuck at whatever the previous refresh saw. No bars can
+// be added afterwards.
+func (d *downloadSession) wait() {
+	d.p.Wait()
+}
+
+// downloadChapters downloads a single series in a session of its own (see
+// downloadSession.download), then saves its series.json and cover files
+func downloadChapters(s grabber.Site, cfg *grabber.Settings, url, title string, chapters grabber.Filterables, lgr *ledger.Ledger, upgrades []ledger.Entry) (downloadResult, error) {
+	d := newDownloadSession(s.GetMaxConcurrency().Chapters)
+	res, err := d.download(s, cfg, url, title, chapters, lgr, upgrades)
+	d.wait()
+	saveSeriesMetadata(s, cfg, url, lgr, res)
+	saveCoverFiles(cfg, url, lgr, res, false)
+
+	return res, err
+}
+
+// download downloads and packs the given chapters of a series, showing their
+// progress bars, and records every saved chapter in lgr. Chapters matching
+// one of the upgrades entries replace the file it records (see
+// pendingChapters). Chapters failing on their own are reported as they happen
+// and counted in the result; the returned error is reserved for the bundle
+// files that couldn't be saved, which lose every chapter in them at once.
+//
+// Besides the session's budget, the site's own chapter concurrency applies.
+func (d *downloadSession) download(s grabber.Site, cfg *grabber.Settings, url, title string, chapters grabber.Filterables, lgr *ledger.Ledger, upgrades []ledger.Entry) (downloadResult, error) {
+	res := downloadResult{}
+	// guards res and upgrades, written from the chapter goroutines
+	mu := sync.Mutex{}
+	failed := func() {
+		mu.Lock()
+		res.Failed++
+		mu.Unlock()
+	}
+	// takeUpgrade returns the entry of the file the chapter replaces, if any
+	upgrades = slices.Clone(upgrades)
+	takeUpgrade := func(chapter *grabber.Chapter) (ledger.Entry, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		for i, e := range upgrades {
+			if e.Matches(chapter) {
+				upgrades = slices.Delete(upgrades, i, i+1)
+				return e, true
+			}
+		}
+		return ledger.Entry{}, false
+	}
+
+	wg := sync.WaitGroup{}
+	g := make(chan struct{}, s.GetMaxConcurrency().Chapters)
+
+	// Current phase for bundle mode progress
+	var currentPhase string
+
+	// progress bar
+	p := d.p
+
+	blue := color.New(color.FgBlue)
+
+	// Get terminal width for title truncation
+	termWidth := getTerminalWidth()
+	mangaLen, chapterLen := calculateTitleLengths(termWidth)
+
+	// sites like mangadex can return the same chapter multiple times, once per
+	// translated language; tag each bar with its language in that case
+	showLanguage := hasDuplicateChapterNumbers(chapters)
+
+	// For bundle mode, create a single progress bar
+	var bundleBar *mpb.Bar
+	if cfg.Bundle {
+		// Calculate total pages for bundle mode
+		totalPages := int64(0)
+		for _, chap := range chapters {
+			chapter, err := s.FetchChapter(chap)
+			if err == nil && chapter != nil {
+				totalPages += chapter.PagesCount
+			}
+		}
+
+		// Total steps = download + archive for all pages
+		bundleBar = p.AddBar(totalPages*2,
+			mpb.PrependDecorators(
+				// Dynamic status showing current chapter
+				decor.Any(func(s decor.Statistics) string {
+					return blue.Sprintf("%-30s", currentPhase)
+				}, decor.WCSyncWidthR),
+				decor.CountersNoUnit("%d/%d", decor.WC{C: decor.DextraSpace}),
+			),
+			mpb.AppendDecorators(
+				decor.Percentage(decor.WC{W: 4}), // W: 4 to remove space before %
+				// Status at the end to prevent shifting
+				decor.Any(func(s decor.Statistics) string {
+					if s.Current >= s.Total {
+						return blue.Sprintf(" bundling ")
+					}
+					return blue.Sprintf(" downloading")
+				}, decor.WC{W: 10}),
+			),
+		)
+		currentPhase = "Gathering info..."
+	}
+
+	// Bundling, each chapter is written into the bundle as soon as the ones
+	// before it are, waiting for its turn (turns[i]) once downloaded. It keeps
+	// its slot of the site's chapter concurrency meanwhile, so no more chapters
+	// than that are ever held in memory, whatever the bundle's size.
+	var bundle *packer.BundleWriter
+	var turns []chan struct{}
+	if cfg.Bundle {
+		chapters = slices.Clone(chapters).SortByNumber()
+		limits, _ := bundleLimits(cfg)
+		bundle = packer.NewBundleWriter(cfg.OutputDir, s, pageOptions, cfg.BundleBy, cfg.Range, limits, func(page, _ int) {
+			bundleBar.IncrBy(page)
+		})
+		turns = make([]chan struct{}, len(chapters)+1)
+		for i := range turns {
+			turns[i] = make(chan struct{})
+		}
+		close(turns[0])
+	}
+
+	if cfg.Cover != packer.CoverNone {
+		if cover := fetchCover(s); cover != nil {
+			if cfg.Cover != packer.CoverBundle {
+				res.Cover = cover
+			}
+			if cfg.Cover != packer.CoverFile && bundle != nil {
+				bundle.SetCover(cover)
+			}
+		}
+	}
+
+	for i, chap := range chapters {
+		g <- struct{}{}
+		wg.Add(1)
+
+		go func(i int, chap grabber.Filterable) {
+			defer wg.Done()
+			defer func() { <-g }()
+			// the chapter downloaded, to be bundled
+			var bundled *packer.DownloadedChapter
+			if cfg.Bundle {
+				defer func() {
+					<-turns[i]
+					if bundled != nil {
+						currentPhase = fmt.Sprintf("Bundling %s", bundled.GetTitle())
+						if err := bundle.Add(bundled); err != nil {
+							color.Red("- error bundling chapter %s: %s", bundled.GetTitle(), err.Error())
+							failed()
+						}
+					}
+					close(turns[i+1])
+				}()
+			}
+			// released first: the session's budget isn't held waiting for a turn
+			d.chapters <- struct{}{}
+			defer func() { <-d.chapters }()
+
+			chapter, err := s.FetchChapter(chap)
+			if err != nil {
+				color.Red("- error fetching chapter %s: %s", chap.GetTitle(), err.Error())
+				failed()
+				return
+			}
+
+			// generate the filename for the chapter
+			filename, err := packer.NewFilenameFromTemplate(s.GetFilenameTemplate(), packer.NewChapterFileTemplateParts(title, chapter))
+			if err != nil {
+				color.Red("- error creating filename for chapter %s: %s", chapter.GetTitle(), err.Error())
+				failed()
+				return
+			}
+			// raw format writes a folder, not a single file
+			filename += packer.Extension(cfg.Format)
+
+			var bar *mpb.Bar
+			if !cfg.Bundle {
+				// For non-bundle mode, create a single bar per chapter that combines download + archive
+				// (using the fetched chapter, whose title includes the chapter number)
+				barTitle := chapterBarTitle(title, chapter, mangaLen, chapterLen, showLanguage)
+				// Total steps = pages (download) + pages (archive)
+				total := chapter.PagesCount * 2
+				bar = p.AddBar(total,
+					mpb.PrependDecorators(
+						decor.Name(barTitle, decor.WCSyncWidthR),
+						decor.CountersNoUnit("%d/%d", decor.WC{C: decor.DextraSpace}),
+					),
+					mpb.AppendDecorators(
+						decor.Percentage(decor.WC{W: 4}), // W: 4 to remove space before %
+						// Status at the end to prevent shifting
+						decor.Any(func(s decor.Statistics) string {
+							if s.Current >= total/2 {
+								return blue.Sprintf(" archiving ")
+							}
+							return blue.Sprintf(" downloading") // Add space before status
+						}, decor.WC{W: 10}),
+					),
+				)
+			} else {
+				bar = bundleBar
+				currentPhase = fmt.Sprintf("Downloading %s", chapter.GetTitle())
+			}
+
+			spool := downloader.NewSpool(cfg.OutputDir, url, chapter)
+			files, err := downloader.FetchChapter(s, chapter, spool, func(page int, idx int, err error) {
+				if err != nil {
+					if !cfg.Bundle {
+						bar.SetCurrent(int64(idx))
+					}
+					color.Red("- error downloading page %d: %s", idx+1, err.Error())
+				} else {
+					// Increment by 1 since we're processing one page at a time
+					bar.IncrBy(1)
+				}
+			})
+			if err != nil {
+				color.Red("- error downloading chapter %s: %s", chapter.GetTitle(), err.Error())
+				if !cfg.Bundle {
+					bar.Abort(false)
+				}
+				failed()
+				return
+			}
+
+			d := &packer.DownloadedChapter{
+				Chapter: chapter,
+				Files:   files,
+				Spool:   spool,
+			}
+
+			if !cfg.Bundle {
+				old, upgrading := takeUpgrade(chapter)
+				if upgrading {
+					d.Replaces = old.Filename
+					if cfg.UpgradeBackup {
+						if err := packer.Backup(cfg.OutputDir, old.Filename); err != nil {
+							color.Red("- error backing up %s, keeping it: %s", old.Filename, err.Error())
+							bar.Abort(false)
+							failed()
+							return
+						}
+					}
+				}
+
+				filename, err := packer.PackSingle(cfg.OutputDir, s, pageOptions, d, func(page, _ int) {
+					bar.IncrBy(1) // Increment archive progress
+				})
+				if err == nil && upgrading {
+					if err := lgr.Forget(url, old); err != nil {
+						color.Yellow("- warning: could not update %s in the download ledger: %s", old.Filename, err.Error())
+					}
+				}
+				if errors.Is(err, packer.ErrSkipped) {
+					// --on-exists skip takes the file already there for this
+					// chapter, so it's recorded as saved
+					color.Yellow("- skipping %s: file already exists", filename)
+					recordChapters(lgr, url, title, filename, chapter)
+				} else if err != nil {
+					color.Red(err.Error())
+					failed()
+				} else {
+					recordChapters(lgr, url, title, filename, chapter)
+					mu.Lock()
+					res.Files = append(res.Files, filename)
+					res.Chapters = append(res.Chapters, chapter)
+					mu.Unlock()
+				}
+				if !bar.Completed() {
+					// the bar can no longer reach its total (packing failed or
+					// pages were skipped); mark it done so p.Wait() won't hang
+					bar.Abort(false)
+				}
+			} else {
+				bundled = d
+			}
+		}(i, chap)
+	}
+	// wait for all routines to finish
+	wg.Wait()
+	close(g)
+
+	if !cfg.Bundle {
+		// if we're not bundling, we're done
+		return res, nil
+	}
+
+	currentPhase = fmt.Sprintf("Finishing bundle for chapters %s", cfg.Range)
+	parts := bundle.Close()
+
+	if !bundleBar.Completed() {
+		// failed chapters leave the bundle bar short of its total; mark it
+		// done so p.Wait() won't hang
+		bundleBar.Abort(false)
+	}
+
+	return res, recordBundle(lgr, url, title, parts, &res)
+}
+
+// saveSeriesMetadata writes (or updates) the series.json of the series
+// downloaded, when the site tells its details (see grabber.MetadataFetcher)
+// and the output dir is the series' own folder: the only series in its
+// ledger (the series of a run saved into the same dir don't even get here,
+// see seriesJob.shared). Failing to do so is only a warning.
+func saveSeriesMetadata(s grabber.Site, cfg *grabber.Settings, url string, lgr *ledger.Ledger, res downloadResult) {
+	fetcher, ok := s.(grabber.MetadataFetcher)
+	if !ok || len(res.Chapters) == 0 || !lgr.Only(url) {
+		return
+	}
+
+	meta, err := fetcher.FetchMetadata()
+	if err == nil {
+		if meta.Language == "" {
+			meta.Language = cfg.Language
+		}
+		err = packer.SaveSeriesJSON(cfg.OutputDir, meta)
+	}
+	if err != nil {
+		color.Yellow("- warning: could not save %s: %s", packer.SeriesFilename, err.Error())
+	}
+}
+
+// saveCoverFiles saves the series cover as files (see packer.SaveCover), once
+// its chapters are saved, when the output dir is the series' own folder: the
+// only series in its ledger, and not shared with other series of the run (see
+// seriesJob.shared), whose covers would replace each other's. It's only
+// warned about otherwise, as failing to save them is.
+func saveCoverFiles(cfg *grabber.Settings, url string, lgr *ledger.Ledger, res downloadResult, shared bool) {
+	if len(res.Cover) == 0 || len(res.Chapters) == 0 {
+		return
+	}
+	if shared || !lgr.Only(url) {
+		color.Yellow("- warning: not saving the cover files, %s holds other series too", cfg.OutputDir)
+		return
+	}
+
+	if err := packer.SaveCover(cfg.OutputDir, res.Cover); err != nil {
+		color.Yellow("- warning: could not save the cover: %s", err.Error())
+	}
+}
+
+// recordBundle records the outcome of packing the parts of a bundle: the
+// chapters of every part saved (or skipped, its file being there already) are
+// added to the ledger and the result. A part failing loses its own chapters
+// alone; the errors of every failed part are returned.
+func recordBundle(lgr *ledger.Ledger, url, title string, parts []packer.BundlePart, res *downloadResult) error {
+	errs := []error{}
+	for _, part := range parts {
+		if errors.Is(part.Err, packer.ErrSkipped) {
+			color.Yellow("- skipping %s: file already exists", part.Name)
+			recordChapters(lgr, url, title, part.Name, bundledChapters(part.Chapters)...)
+			continue
+		}
+		if part.Err != nil {
+			errs = append(errs, part.Err)
+			continue
+		}
+
+		recordChapters(lgr, url, title, part.Name, bundledChapters(part.Chapters)...)
+		res.Files = append(res.Files, part.Name)
+		res.Chapters = append(res.Chapters, bundledChapters(part.Chapters)...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// fetchCover downloads the series cover, when the site has one (see
+// grabber.Coverer). Failing to do so is only a warning: the chapters are
+// saved all the same, without it.
+func fetchCover(s grabber.Site) []byte {
+	coverer, ok := s.(grabber.Coverer)
+	if !ok {
+		return nil
+	}
+	url, err := coverer.FetchCover()
+	if err == nil && url == "" {
+		return nil
+	}
+	var file *downloader.File
+	if err == nil {
+		file, err = downloader.FetchPooled(http.RequestParams{
+			URL:     url,
+			Referer: s.BaseUrl(),
+		}, 0, s.GetRetries(), nil)
+	}
+	if err != nil {
+		color.Yellow("- warning: could not download the cover: %s", err.Error())
+		return nil
+	}
+
+	return file.Data
+}
+
+// bundleLimits returns the limits of the bundle parts (see
+// packer.BundleLimits) set in cfg
+func bundleLimits(cfg *grabber.Settings) (packer.BundleLimits, error) {
+	limits := packer.BundleLimits{Pages: cfg.BundleMaxPages}
+	if limits.Pages < 0 {
+		return limits, fmt.Errorf("invalid --bundle-max-pages value %d, must be positive", limits.Pages)
+	}
+	if cfg.BundleMaxSize != "" {
+		size, err := parseSize(cfg.BundleMaxSize)
+		if err != nil {
+			return limits, fmt.Errorf("invalid --bundle-max-size value: %w", err)
+		}
+		limits.Size = size
+	}
+
+	return limits, nil
+}
+
+// sizeUnits are the multipliers of the units parseSize understands
+var sizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"kib": 1 << 10,
+	"m":   1e6,
+	"mb":  1e6,
+	"mib": 1 << 20,
+	"g":   1e9,
+	"gb":  1e9,
+	"gib": 1 << 30,
+}
+
+// parseSize parses a size in bytes, with an optional unit: "700MB", "1.5G",
+// "512MiB" or "1000000"
+func parseSize(s string) (int64, error) {
+	value := strings.TrimSpace(s)
+	i := strings.IndexFunc(value, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(value)
+	}
+
+	unit, ok := sizeUnits[strings.ToLower(strings.TrimSpace(value[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit in size %q (use B, KB, MB, GB or KiB, MiB, GiB)", s)
+	}
+	n, err := strconv.ParseFloat(value[:i], 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	return int64(n * float64(unit)), nil
+}
+
+// recordChapters adds the chapters saved into filename (a single one, or every
+// chapter of a bundle) to the ledger. Failing to do so is only a warning: the
+// chapters themselves were saved just fine, they'd simply be downloaded again
+// on the next run.
+func recordChapters(l *ledger.Ledger, url, title, filename string, chapters ...*grabber.Chapter) {
+	if err := l.RecordFile(url, title, filename, chapters...); err != nil {
+		color.Yellow("- warning: could not record %s in the download ledger: %s", filename, err.Error())
+	}
+}
+
+// bundledChapters returns the chapters of a bundle
+func bundledChapters(dc []*packer.DownloadedChapter) []*grabber.Chapter {
+	chapters := make([]*grabber.Chapter, len(dc))
+	for i, d := range dc {
+		chapters[i] = d.Chapter
+	}
+
+	return chapters
+}
@@ -0,0 +1,66 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/elboletaire/manga-downloader/grabber"
+)
+
+func TestParseSize(t *testing.T) {
+	cases := map[string]int64{
+		"1000":   1000,
+		"700MB":  700e6,
+		"700 mb": 700e6,
+		"1.5G":   1.5e9,
+		"512MiB": 512 << 20,
+		"2gib":   2 << 30,
+	}
+	for input, want := range cases {
+		if got, err := parseSize(input); err != nil || got != want {
+			t.Errorf("parseSize(%q) = %d, %v; want %d", input, got, err, want)
+		}
+	}
+
+	for _, input := range []string{"", "MB", "0", "-5MB", "12 parsecs"} {
+		if _, err := parseSize(input); err == nil {
+			t.Errorf("parseSize(%q): expected an error", input)
+		}
+	}
+}
+
+func TestValidateBundleLimits(t *testing.T) {
+	cfg := grabber.Settings{Format: "cbz", ReadingDirection: "rtl", OnExists: "version", BundleBy: "range", Cover: "none", BundleMaxSize: "700MB"}
+	if err := validateSettings(&cfg); err == nil {
+		t.Error("expected an error for bundle limits without --bundle")
+	}
+
+	cfg.Bundle = true
+	if err := validateSettings(&cfg); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	cfg.BundleMaxSize = "lots"
+	if err := validateSettings(&cfg); err == nil {
+		t.Error("expected an error for an invalid size")
+	}
+}
+
+func TestValidateCover(t *testing.T) {
+	cfg := grabber.Settings{Format: "cbz", ReadingDirection: "rtl", OnExists: "version", BundleBy: "range", Cover: "bundle"}
+	if err := validateSettings(&cfg); err == nil {
+		t.Error("expected an error for --cover bundle without --bundle")
+	}
+
+	// both only saves the cover files when there are no bundles
+	cfg.Cover = "both"
+	if err := validateSettings(&cfg); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	cfg.Cover = "poster"
+	if err := validateSettings(&cfg); err == nil {
+		t.Error("expected an error for an invalid cover mode")
+	}
+}
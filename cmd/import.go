@@ -0,0 +1,154 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/elboletaire/manga-downloader/browser"
+	"github.com/elboletaire/manga-downloader/ledger"
+	"github.com/elboletaire/manga-downloader/packer"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import [flags] [url]",
+	Short: "Records the chapters already in the output directory in its download ledger",
+	Long: `Records the chapters of a series already in the output directory (and its subdirectories) in its download ledger, so they're skipped by the next downloads and updates instead of downloaded again.
+
+Files are recognised by their names, following the filename template: the one used to save them must be passed with --filename-template unless it's the default. Only single chapters are imported, bundles are left out.`,
+	Example: colorizeHelp(`  manga-downloader import -o ~/manga/one-piece https://inmanga.com/ver/manga/One-Piece/dfc7ecb5-e9b3-4aa5-a61b-a498993cd935
+
+Would record the One Piece chapters in ~/manga/one-piece, so only the ones missing from it are downloaded from now on.`),
+	Args: cobra.ExactArgs(1),
+	Run:  runImport,
+}
+
+// runImport records the series' chapters found in the output dir
+func runImport(cmd *cobra.Command, args []string) {
+	defer browser.Close()
+	browser.SetVisible(settings.BrowserVisible)
+
+	cerr(validateSettings(&settings), "Error: ")
+
+	url := args[0]
+	s, err := newSite(url, &settings, cmd)
+	if err != nil {
+		color.Yellow(err.Error())
+		exit(1)
+	}
+
+	// the title tells the series' files apart from other series' ones
+	title, err := s.FetchTitle()
+	cerr(err, "Error fetching title: ")
+
+	lgr, err := ledger.Open(settings.OutputDir)
+	cerr(err, "Error reading the download ledger: ")
+
+	entries, skipped, err := importLibrary(settings.OutputDir, settings.FilenameTemplate, title, url, lgr)
+	cerr(err, "Error reading the output directory: ")
+	for _, msg := range skipped {
+		color.Yellow("- skipping %s", msg)
+	}
+
+	if len(entries) == 0 {
+		color.Yellow("No new chapters of %s found in %s", title, settings.OutputDir)
+		return
+	}
+	cerr(lgr.Record(url, title, entries...), "Error saving the download ledger: ")
+
+	fmt.Printf("- %s %s\n", color.GreenString("imported"), color.HiBlackString("%d chapters of %s", len(entries), title))
+}
+
+// importLibrary walks dir for the series' chapters (files of any output
+// format, or folders for raw chapters) named after the filename template, returning a ledger entry
+// for each one the ledger doesn't have yet. Files the template can't tell
+// apart, like bundles, are returned as skipped along with the reason.
+func importLibrary(dir, template, title, url string, lgr *ledger.Ledger) (entries []ledger.Entry, skipped []string, err error) {
+	parser, err := packer.NewFilenameParser(template)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid filename template: %w", err)
+	}
+
+	recorded := map[string]bool{}
+	for _, e := range lgr.Entries(url) {
+		recorded[e.Filename] = true
+	}
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			// the ledger, and the staging folders of interrupted runs
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && packer.FormatOf(d.Name()) == packer.FormatRaw {
+			// not a file of any output format
+			return nil
+		}
+
+		parts, ok := parser.Parse(d.Name())
+		if !ok || !isSeriesFile(parts, title) {
+			// a folder not named after a chapter may be holding some
+			return nil
+		}
+
+		if d.IsDir() {
+			// a raw chapter's folder holds its pages, not more chapters
+			err = filepath.SkipDir
+		}
+		rel, _ := filepath.Rel(dir, path)
+		if recorded[rel] {
+			return err
+		}
+
+		entry, reason := importEntry(rel, path, parts)
+		if reason != "" {
+			skipped = append(skipped, fmt.Sprintf("%s: %s", rel, reason))
+		} else {
+			entries = append(entries, entry)
+		}
+
+		return err
+	})
+
+	return entries, skipped, err
+}
+
+// importEntry returns the ledger entry of the chapter saved at path (rel,
+// relative to the output dir), or the reason it can't be imported
+func importEntry(rel, path string, parts packer.FilenameTemplateParts) (ledger.Entry, string) {
+	entry := ledger.Entry{Filename: rel}
+
+	number, err := strconv.ParseFloat(parts.Number, 64)
+	if err != nil {
+		return entry, "bundles can't be imported"
+	}
+	entry.Number = number
+
+	if entry.Pages, err = packer.CountPages(path); err != nil {
+		return entry, fmt.Sprintf("reading pages: %s", err)
+	}
+	if entry.Hash, err = ledger.HashPath(path); err != nil {
+		return entry, fmt.Sprintf("hashing: %s", err)
+	}
+
+	return entry, ""
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+}
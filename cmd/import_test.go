@@ -0,0 +1,88 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elboletaire/manga-downloader/grabber"
+	"github.com/elboletaire/manga-downloader/ledger"
+	"github.com/elboletaire/manga-downloader/packer"
+)
+
+func TestImportLibrary(t *testing.T) {
+	dir := t.TempDir()
+	url := "https://example.com/series"
+	noop := func(page, progress int) {}
+	pages := []packer.File{{Name: "000.jpg", Data: []byte("a")}, {Name: "001.jpg", Data: []byte("b")}}
+
+	for _, name := range []string{
+		"Series 1 - One.cbz",
+		filepath.Join("volume 1", "Series 2 - Two v2.cbz"),
+		"Series 1-8 - bundle.cbz",
+		"Other 3 - Three.cbz",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := packer.ArchiveCBZ(path, packer.Metadata{}, pages, noop); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := packer.SaveRaw(filepath.Join(dir, "Series 3 - Three"), pages[:1], noop); err != nil {
+		t.Fatal(err)
+	}
+	if err := packer.ArchiveCBZ(filepath.Join(dir, "Series 4 - Four.cbz"), packer.Metadata{}, pages, noop); err != nil {
+		t.Fatal(err)
+	}
+	if err := packer.ArchiveCBT(filepath.Join(dir, "Series 5 - Five.cbt"), packer.Metadata{}, pages, noop); err != nil {
+		t.Fatal(err)
+	}
+	if err := packer.ArchiveEPUB(filepath.Join(dir, "Series 6 - Six.epub"), packer.Metadata{}, pages, noop); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Series 7 - Notes.txt"), []byte("not a chapter"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lgr, err := ledger.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// already recorded files aren't imported twice
+	if err := lgr.RecordFile(url, "Series", "Series 4 - Four.cbz", &grabber.Chapter{Number: 4}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, skipped, err := importLibrary(dir, packer.FilenameTemplateDefault, "Series", url, lgr)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(skipped) != 1 {
+		t.Errorf("expected the bundle to be skipped, got %v", skipped)
+	}
+
+	want := map[string]struct {
+		number float64
+		pages  int64
+	}{
+		"Series 1 - One.cbz": {1, 2},
+		filepath.Join("volume 1", "Series 2 - Two v2.cbz"): {2, 2},
+		"Series 3 - Three":    {3, 1},
+		"Series 5 - Five.cbt": {5, 2},
+		"Series 6 - Six.epub": {6, 2},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got %+v", len(want), entries)
+	}
+	for _, e := range entries {
+		w, ok := want[e.Filename]
+		if !ok || e.Number != w.number || e.Pages != w.pages || e.Hash == "" {
+			t.Errorf("unexpected entry %+v", e)
+		}
+	}
+}
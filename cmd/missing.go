@@ -0,0 +1,206 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/elboletaire/manga-downloader/browser"
+	"github.com/elboletaire/manga-downloader/grabber"
+	"github.com/elboletaire/manga-downloader/ledger"
+	"github.com/elboletaire/manga-downloader/packer"
+	"github.com/elboletaire/manga-downloader/ranges"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// missingAsRanges prints the missing chapters as a range string only
+var missingAsRanges bool
+
+// missingCmd represents the missing command
+var missingCmd = &cobra.Command{
+	Use:   "missing [flags] [url]",
+	Short: "Lists the chapters of a series missing from the output directory",
+	Long: `Lists the chapters of a series available on the site but missing from the output directory.
+
+A chapter counts as present when the download ledger records it, or when a file in the output directory is named after it following the filename template (bundles count for every chapter in their range).`,
+	Example: colorizeHelp(`  manga-downloader missing -o ~/manga/one-piece https://inmanga.com/ver/manga/One-Piece/dfc7ecb5-e9b3-4aa5-a61b-a498993cd935
+
+Would list the One Piece chapters missing from ~/manga/one-piece.
+
+  manga-downloader https://inmanga.com/ver/manga/One-Piece/dfc7ecb5-e9b3-4aa5-a61b-a498993cd935 -o ~/manga/one-piece $(manga-downloader missing --ranges -o ~/manga/one-piece https://inmanga.com/ver/manga/One-Piece/dfc7ecb5-e9b3-4aa5-a61b-a498993cd935)
+
+Would download them.`),
+	Args: cobra.ExactArgs(1),
+	Run:  runMissing,
+}
+
+// runMissing lists the chapters of the series missing from the output dir
+func runMissing(cmd *cobra.Command, args []string) {
+	defer browser.Close()
+	browser.SetVisible(settings.BrowserVisible)
+
+	cerr(validateSettings(&settings), "Error: ")
+
+	url := args[0]
+	s, err := newSite(url, &settings, cmd)
+	if err != nil {
+		color.Yellow(err.Error())
+		exit(1)
+	}
+
+	title, err := s.FetchTitle()
+	cerr(err, "Error fetching title: ")
+
+	chapters, err := fetchChapterList(s, &settings)
+	if err != nil {
+		color.Red(err.Error())
+		exit(1)
+	}
+
+	lgr, err := ledger.Open(settings.OutputDir)
+	cerr(err, "Error reading the download ledger: ")
+	local, err := localChapters(settings.OutputDir, settings.FilenameTemplate, title, url, lgr)
+	cerr(err, "Error reading the output directory: ")
+
+	all := chapterNumbers(chapters)
+	missing := missingNumbers(all, local)
+
+	if missingAsRanges {
+		if len(missing) > 0 {
+			fmt.Println(compactRanges(all, missing))
+		}
+		return
+	}
+
+	if len(missing) == 0 {
+		color.Green("No chapters missing, all %d are in %s", len(all), settings.OutputDir)
+		return
+	}
+
+	numbers := make([]string, len(missing))
+	for i, n := range missing {
+		numbers[i] = fmt.Sprintf("%g", n)
+	}
+	fmt.Printf("%s %s\n", color.YellowString("%d of %d chapters missing:", len(missing), len(all)), strings.Join(numbers, ", "))
+	fmt.Println(color.HiBlackString("download them with: manga-downloader %s %s", url, compactRanges(all, missing)))
+}
+
+// localChapters returns the chapter numbers present in dir for the series:
+// the ones its ledger records, plus the ones parsed from the names of the
+// files (and raw folders) following the filename template. Files named after
+// another series are ignored when the template includes the series name.
+func localChapters(dir, template, title, url string, lgr *ledger.Ledger) ([]ranges.Range, error) {
+	local := []ranges.Range{}
+	for _, e := range lgr.Entries(url) {
+		local = append(local, ranges.Range{Begin: e.Number, End: e.Number})
+	}
+
+	parser, err := packer.NewFilenameParser(template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filename template: %w", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			// the ledger, and the staging folders of interrupted runs
+			continue
+		}
+
+		parts, ok := parser.Parse(entry.Name())
+		if !ok || !isSeriesFile(parts, title) {
+			continue
+		}
+		rngs, err := ranges.Parse(parts.Number)
+		if err != nil {
+			continue
+		}
+		local = append(local, rngs...)
+	}
+
+	return local, nil
+}
+
+// isSeriesFile tells whether a file name parsed into parts belongs to the
+// series titled title. Names built from a template without the series name
+// can't tell, so they always do.
+func isSeriesFile(parts packer.FilenameTemplateParts, title string) bool {
+	// single chapters use the sanitized title, bundles the title as is
+	return parts.Series == "" || parts.Series == packer.SanitizeFilename(title) || parts.Series == title
+}
+
+// chapterNumbers returns the distinct chapter numbers, sorted
+func chapterNumbers(chapters grabber.Filterables) []float64 {
+	seen := map[float64]bool{}
+	numbers := []float64{}
+	for _, c := range chapters {
+		if !seen[c.GetNumber()] {
+			seen[c.GetNumber()] = true
+			numbers = append(numbers, c.GetNumber())
+		}
+	}
+	sort.Float64s(numbers)
+
+	return numbers
+}
+
+// missingNumbers returns the numbers of all not covered by any local range
+func missingNumbers(all []float64, local []ranges.Range) []float64 {
+	missing := []float64{}
+	for _, n := range all {
+		present := false
+		for _, r := range local {
+			if n >= r.Begin && n <= r.End {
+				present = true
+				break
+			}
+		}
+		if !present {
+			missing = append(missing, n)
+		}
+	}
+
+	return missing
+}
+
+// compactRanges returns missing as a range string ready for ranges.Parse,
+// merging runs of chapters that follow each other in all (e.g. "1-3,7"). A
+// run only ever spans missing chapters: any chapter between its ends would
+// have broken it.
+func compactRanges(all, missing []float64) string {
+	isMissing := map[float64]bool{}
+	for _, n := range missing {
+		isMissing[n] = true
+	}
+
+	parts := []string{}
+	for i := 0; i < len(all); i++ {
+		if !isMissing[all[i]] {
+			continue
+		}
+		j := i
+		for j+1 < len(all) && isMissing[all[j+1]] {
+			j++
+		}
+		if i == j {
+			parts = append(parts, fmt.Sprintf("%g", all[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%g-%g", all[i], all[j]))
+		}
+		i = j
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func init() {
+	missingCmd.Flags().BoolVar(&missingAsRanges, "ranges", false, "only print the missing chapters as a range string, to pass on to a download")
+	rootCmd.AddCommand(missingCmd)
+}
@@ -0,0 +1,73 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/elboletaire/manga-downloader/grabber"
+	"github.com/elboletaire/manga-downloader/ledger"
+	"github.com/elboletaire/manga-downloader/packer"
+)
+
+func TestLocalChapters(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"Series 1 - One.cbz",
+		"Series 2 - Two v2.cbz",
+		"Series 5-7 - bundle.cbz",
+		"Other 3 - Three.cbz",
+		"notes.txt",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// raw chapters are folders
+	if err := os.Mkdir(filepath.Join(dir, "Series 9 - Nine"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	lgr, err := ledger.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "renamed.cbz"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := lgr.RecordFile("https://example.com/series", "Series", "renamed.cbz", &grabber.Chapter{Number: 12}); err != nil {
+		t.Fatal(err)
+	}
+
+	local, err := localChapters(dir, packer.FilenameTemplateDefault, "Series", "https://example.com/series", lgr)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	all := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	want := []float64{3, 4, 8, 10, 11}
+	if got := missingNumbers(all, local); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v missing, got %v", want, got)
+	}
+}
+
+func TestCompactRanges(t *testing.T) {
+	all := []float64{1, 2, 3, 4, 4.5, 5, 6, 8, 10}
+	cases := []struct {
+		missing []float64
+		want    string
+	}{
+		{[]float64{1, 2, 3}, "1-3"},
+		{[]float64{4, 5}, "4,5"}, // 4.5 is in between and present
+		{[]float64{2, 4, 4.5, 5, 8, 10}, "2,4-5,8-10"},
+		{[]float64{6}, "6"},
+	}
+	for _, c := range cases {
+		if got := compactRanges(all, c.missing); got != c.want {
+			t.Errorf("%v: expected %q, got %q", c.missing, c.want, got)
+		}
+	}
+}
@@ -3,23 +3,23 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
 	"strings"
-	"sync"
 	"syscall"
 
 	"github.com/elboletaire/manga-downloader/browser"
 	"github.com/elboletaire/manga-downloader/downloader"
 	"github.com/elboletaire/manga-downloader/grabber"
+	"github.com/elboletaire/manga-downloader/ledger"
 	"github.com/elboletaire/manga-downloader/packer"
+	"github.com/elboletaire/manga-downloader/packer/pageopts"
 	"github.com/elboletaire/manga-downloader/ranges"
 	"github.com/fatih/color"
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
-	"github.com/vbauerster/mpb/v8"
-	"github.com/vbauerster/mpb/v8/decor"
 	"golang.org/x/term"
 
 	cc "github.com/ivanpirog/coloredcobra"
@@ -27,9 +27,16 @@ import (
 
 var settings grabber.Settings
 
+// pageOptions are how pages are reworked before packing, read from the flags
+// by readPageOptions
+var pageOptions pageopts.Options
+
+// the limits of the process-wide page download pool (see downloader.Pool)
+var maxConnections, maxHostConnections int
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use:   "manga-downloader [flags] [url] [ranges]",
+	Use:   "manga-downloader [flags] [url] [ranges] [url [ranges]]...",
 	Short: "Helps you download mangas from websites to CBZ files",
 
 	Long: `With manga-downloader you can easily convert/download web based mangas to CBZ files.
@@ -59,69 +66,69 @@ It would also download chapters 10 to 20 of Black Clover from mangadex.org in Sp
 
 Note arguments aren't really positional, you can specify them in any order:
 
-  manga-downloader --language es 10-20 https://mangadex.org/title/e7eabe96-aa17-476f-b431-2497d5e9d060/black-clover --bundle`),
-	Args: cobra.MinimumNArgs(1),
-	Run:  Run,
+  manga-downloader --language es 10-20 https://mangadex.org/title/e7eabe96-aa17-476f-b431-2497d5e9d060/black-clover --bundle
+
+Several series can be downloaded at once, each url followed by its range (every chapter, if none):
+
+  manga-downloader https://inmanga.com/ver/manga/Dr-Stone/d9e47ba6-7dfc-401d-a21c-19326c2ea45f 1-10 https://inmanga.com/ver/manga/Fire-Punch/17748683-8986-4628-934a-e94a47fe5d59
+
+Several series can be downloaded in one go from a file, one "<url> <ranges> [--language xx] [--scanlator yy]" per line:
+
+  manga-downloader --input-file series.txt`),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if inputFile != "" {
+			if len(args) > 0 {
+				return errors.New("--input-file takes no url or ranges arguments")
+			}
+			return nil
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
+	Run: Run,
 }
 
 // Run is the main function of the root command, the main downloading cmd
 func Run(cmd *cobra.Command, args []string) {
+	if inputFile != "" {
+		runBatch(cmd)
+		return
+	}
+
 	// ensure the shared Chrome process (if any) is killed on exit
 	defer browser.Close()
 	browser.SetVisible(settings.BrowserVisible)
 
-	if settings.Format != packer.FormatCBZ && settings.Format != packer.FormatRaw {
-		color.Red("Error: invalid format %q, must be %q or %q", settings.Format, packer.FormatCBZ, packer.FormatRaw)
-		exit(1)
-	}
+	cerr(validateSettings(&settings), "Error: ")
+	cerr(readPageOptions(cmd), "Error: ")
 
-	// validated here (and only here) so Grabber.GetConvertImages can ignore the
-	// parse error later, the same way maxUint8Flag ignores its own
-	if _, err := grabber.ParseConvertFormats(settings.ConvertImages); err != nil {
-		color.Red("Error: invalid --convert-images value: %s", err)
-		exit(1)
+	series, err := parseSeriesArgs(args)
+	cerr(err, "Error: ")
+	if len(series) > 1 {
+		runSeries(cmd, series)
+		return
 	}
 
-	s, errs := grabber.NewSite(getUrlArg(args), &settings)
-	if len(errs) > 0 {
-		color.Red("Errors testing site (a site may be down):")
-		for _, err := range errs {
-			color.Red(err.Error())
-		}
-	}
-	if s == nil {
-		color.Yellow("Site not recognised")
+	url := series[0].URL
+	s, err := newSite(url, &settings, cmd)
+	if err != nil {
+		color.Yellow(err.Error())
 		exit(1)
 	}
-	s.InitFlags(cmd)
 
 	// fetch series title
 	title, err := s.FetchTitle()
 	cerr(err, "Error fetching title: ")
 
 	// fetch all chapters
-	chapters, errs := s.FetchChapters()
-	if len(errs) > 0 {
-		color.Red("Errors fetching chapters:")
-		for _, err := range errs {
-			color.Red(err.Error())
-		}
-		exit(1)
-	}
-
-	chapters = chapters.SortByNumber()
-
-	// an empty result at this point usually means the language filter matched
-	// nothing (e.g. an invalid language code); without this guard the prompt
-	// path below would panic indexing an empty slice
-	if len(chapters) == 0 {
-		color.Yellow(noChaptersMessage(settings.Language, settings.Scanlator))
+	chapters, err := fetchChapterList(s, &settings)
+	if err != nil {
+		color.Red(err.Error())
 		exit(1)
 	}
 
 	var rngs []ranges.Range
 	// ranges argument is not provided
-	if len(args) == 1 {
+	if series[0].Range == "" {
 		lastChapter := chapters[len(chapters)-1].GetNumber()
 		prompt := promptui.Prompt{
 			Label:     fmt.Sprintf("Do you want to download all %g chapters", lastChapter),
@@ -138,9 +145,9 @@ func Run(cmd *cobra.Command, args []string) {
 		rngs = []ranges.Range{{Begin: 1, End: float64(lastChapter)}}
 	} else {
 		// ranges parsing
-		settings.Range = getRangesArg(args)
-		rngs, err = ranges.Parse(settings.Range)
-		cerr(err, "Error parsing ranges: ")
+		// already validated while parsing the arguments
+		settings.Range = series[0].Range
+		rngs, _ = ranges.Parse(settings.Range)
 	}
 
 	// sort and filter specified ranges
@@ -151,217 +158,31 @@ func Run(cmd *cobra.Command, args []string) {
 		exit(1)
 	}
 
-	// download chapters
-	wg := sync.WaitGroup{}
-	g := make(chan struct{}, s.GetMaxConcurrency().Chapters)
-	downloaded := grabber.Filterables{}
-
-	// Current phase for bundle mode progress
-	var currentPhase string
-
-	// progress bar
-	p := mpb.New(
-		mpb.WithWidth(40),
-		mpb.WithOutput(color.Output),
-		mpb.WithAutoRefresh(),
-	)
-
-	blue := color.New(color.FgBlue)
-
-	// Get terminal width for title truncation
-	termWidth := getTerminalWidth()
-	mangaLen, chapterLen := calculateTitleLengths(termWidth)
-
-	// sites like mangadex can return the same chapter multiple times, once per
-	// translated language; tag each bar with its language in that case
-	showLanguage := hasDuplicateChapterNumbers(chapters)
-
-	// For bundle mode, create a single progress bar
-	var bundleBar *mpb.Bar
-	if settings.Bundle {
-		// Calculate total pages for bundle mode
-		totalPages := int64(0)
-		for _, chap := range chapters {
-			chapter, err := s.FetchChapter(chap)
-			if err == nil && chapter != nil {
-				totalPages += chapter.PagesCount
-			}
-		}
-
-		// Total steps = download + archive for all pages
-		bundleBar = p.AddBar(totalPages*2,
-			mpb.PrependDecorators(
-				// Dynamic status showing current chapter
-				decor.Any(func(s decor.Statistics) string {
-					return blue.Sprintf("%-30s", currentPhase)
-				}, decor.WCSyncWidthR),
-				decor.CountersNoUnit("%d/%d", decor.WC{C: decor.DextraSpace}),
-			),
-			mpb.AppendDecorators(
-				decor.Percentage(decor.WC{W: 4}), // W: 4 to remove space before %
-				// Status at the end to prevent shifting
-				decor.Any(func(s decor.Statistics) string {
-					if s.Current >= s.Total {
-						return blue.Sprintf(" bundling ")
-					}
-					return blue.Sprintf(" downloading")
-				}, decor.WC{W: 10}),
-			),
-		)
-		currentPhase = "Gathering info..."
+	// skip whatever the output dir's ledger says is already saved, so re-runs
+	// only download what's new instead of writing " v2" copies of everything
+	lgr, err := ledger.Open(settings.OutputDir)
+	cerr(err, "Error reading the download ledger: ")
+	chapters, upgrades, skipped := pendingChapters(s, &settings, url, chapters, lgr)
+	if skipped > 0 {
+		color.HiBlack("- skipping %d chapters already downloaded (use --force to download them again)", skipped)
 	}
-
-	for _, chap := range chapters {
-		g <- struct{}{}
-		wg.Add(1)
-
-		go func(chap grabber.Filterable) {
-			defer wg.Done()
-
-			chapter, err := s.FetchChapter(chap)
-			if err != nil {
-				color.Red("- error fetching chapter %s: %s", chap.GetTitle(), err.Error())
-				<-g
-				return
-			}
-
-			// generate the filename for the chapter
-			filename, err := packer.NewFilenameFromTemplate(s.GetFilenameTemplate(), packer.NewChapterFileTemplateParts(title, chapter))
-			if err != nil {
-				color.Red("- error creating filename for chapter %s: %s", chapter.GetTitle(), err.Error())
-				<-g
-				return
-			}
-			if settings.Format != packer.FormatRaw {
-				// raw format writes a folder, not a single file
-				filename += ".cbz"
-			}
-
-			var bar *mpb.Bar
-			if !settings.Bundle {
-				// For non-bundle mode, create a single bar per chapter that combines download + archive
-				// (using the fetched chapter, whose title includes the chapter number)
-				barTitle := chapterBarTitle(title, chapter, mangaLen, chapterLen, showLanguage)
-				// Total steps = pages (download) + pages (archive)
-				total := chapter.PagesCount * 2
-				bar = p.AddBar(total,
-					mpb.PrependDecorators(
-						decor.Name(barTitle, decor.WCSyncWidthR),
-						decor.CountersNoUnit("%d/%d", decor.WC{C: decor.DextraSpace}),
-					),
-					mpb.AppendDecorators(
-						decor.Percentage(decor.WC{W: 4}), // W: 4 to remove space before %
-						// Status at the end to prevent shifting
-						decor.Any(func(s decor.Statistics) string {
-							if s.Current >= total/2 {
-								return blue.Sprintf(" archiving ")
-							}
-							return blue.Sprintf(" downloading") // Add space before status
-						}, decor.WC{W: 10}),
-					),
-				)
-			} else {
-				bar = bundleBar
-				currentPhase = fmt.Sprintf("Downloading %s", chapter.GetTitle())
-			}
-
-			files, err := downloader.FetchChapter(s, chapter, func(page int, idx int, err error) {
-				if err != nil {
-					if !settings.Bundle {
-						bar.SetCurrent(int64(idx))
-					}
-					color.Red("- error downloading page %d: %s", idx+1, err.Error())
-				} else {
-					// Increment by 1 since we're processing one page at a time
-					bar.IncrBy(1)
-				}
-			})
-			if err != nil {
-				color.Red("- error downloading chapter %s: %s", chapter.GetTitle(), err.Error())
-				if !settings.Bundle {
-					bar.Abort(false)
-				}
-				<-g
-				return
-			}
-
-			d := &packer.DownloadedChapter{
-				Chapter: chapter,
-				Files:   files,
-			}
-
-			if !settings.Bundle {
-				_, err := packer.PackSingle(settings.OutputDir, s, d, func(page, _ int) {
-					bar.IncrBy(1) // Increment archive progress
-				})
-				if err != nil {
-					color.Red(err.Error())
-				}
-				if !bar.Completed() {
-					// the bar can no longer reach its total (packing failed or
-					// pages were skipped); mark it done so p.Wait() won't hang
-					bar.Abort(false)
-				}
-			} else {
-				// For bundle mode, increment archive progress
-				bar.IncrBy(int(chapter.PagesCount))
-				// avoid adding it to memory if we're not gonna use it
-				downloaded = append(downloaded, d)
-			}
-
-			// release guard
-			<-g
-		}(chap)
-	}
-	// wait for all routines to finish
-	wg.Wait()
-	close(g)
-
-	if !settings.Bundle {
-		// let the render loop paint the final state of the bars before
-		// exiting; without this, fast packing (e.g. raw folders) ends with
-		// the last painted frame stuck at whatever the previous refresh saw
-		p.Wait()
-		// if we're not bundling, we're done
+	if len(chapters) == 0 {
+		color.Green("All the specified chapters are already downloaded")
 		exit(0)
 	}
 
-	// resort downloaded
-	downloaded = downloaded.SortByNumber()
-
-	dc := []*packer.DownloadedChapter{}
-	tp := 0
-	// convert slice back to DownloadedChapter
-	for _, d := range downloaded {
-		chapter := d.(*packer.DownloadedChapter)
-		dc = append(dc, chapter)
-		tp += int(chapter.PagesCount)
-	}
-
-	if settings.Bundle {
-		currentPhase = fmt.Sprintf("Creating bundle for chapters %s", settings.Range)
-	}
-
-	filename, err := packer.PackBundle(settings.OutputDir, s, dc, settings.Range, func(page, _ int) {
-		if bundleBar != nil {
-			bundleBar.IncrBy(page)
-		}
-	})
-
-	if bundleBar != nil && !bundleBar.Completed() {
-		// failed chapters leave the bundle bar short of its total; mark it
-		// done so p.Wait() won't hang
-		bundleBar.Abort(false)
-	}
-	// flush the final render before printing the outcome
-	p.Wait()
-
+	res, err := downloadChapters(s, &settings, url, title, chapters, lgr, upgrades)
 	if err != nil {
 		color.Red(err.Error())
 		exit(1)
 	}
 
-	fmt.Printf("- %s %s\n", color.GreenString("saved file"), color.HiBlackString(filename))
+	if settings.Bundle {
+		// a single file, or one per volume
+		for _, file := range res.Files {
+			fmt.Printf("- %s %s\n", color.GreenString("saved file"), color.HiBlackString(file))
+		}
+	}
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -388,15 +209,34 @@ func Execute() {
 // init sets the flags for the root command
 func init() {
 	rootCmd.Flags().BoolVarP(&settings.Bundle, "bundle", "b", false, "bundle all specified chapters into a single file")
-	rootCmd.Flags().Uint8VarP(&settings.MaxConcurrency.Chapters, "concurrency", "c", 5, "number of concurrent chapter downloads, hard-limited to 5")
-	rootCmd.Flags().Uint8VarP(&settings.MaxConcurrency.Pages, "concurrency-pages", "C", 10, "number of concurrent page downloads, hard-limited to 10")
-	rootCmd.Flags().StringVarP(&settings.Language, "language", "l", "", "only download the specified language")
-	rootCmd.Flags().StringVarP(&settings.Scanlator, "scanlator", "s", "", `only download the specified scanlation group, for sites hosting several versions of the same chapters ("all" downloads every group's)`)
-	rootCmd.Flags().StringVarP(&settings.FilenameTemplate, "filename-template", "t", packer.FilenameTemplateDefault, "template for the resulting filename")
-	rootCmd.Flags().StringVarP(&settings.Format, "format", "f", packer.FormatCBZ, "output format: cbz or raw (a folder with the images)")
-	rootCmd.Flags().StringVar(&settings.ConvertImages, "convert-images", grabber.ConvertImagesDefault, `comma-separated source image formats to convert to jpeg for e-reader compatibility: "avif", "webp" or "none"`)
-	rootCmd.Flags().BoolVar(&settings.BrowserVisible, "browser-visible", false, "open the browser window from the start (it opens automatically anyway when a headless attempt hits a challenge)")
-	rootCmd.Flags().Uint8VarP(&settings.Retry, "retry", "r", 1, "number of retries for failed page downloads, hard-limited to 3 (0 disables retrying)")
+	rootCmd.Flags().StringVar(&settings.BundleBy, "bundle-by", packer.BundleByRange, `how to split bundles: "range" (a single file) or "volume" (a file per volume, chapters without one going into a last file)`)
+	rootCmd.Flags().IntVar(&settings.BundleMaxPages, "bundle-max-pages", 0, "split bundles into files of up to this many pages, at chapter boundaries (0 for no limit)")
+	rootCmd.Flags().StringVar(&settings.BundleMaxSize, "bundle-max-size", "", `split bundles into files of up to this size, at chapter boundaries, e.g. "700MB" or "2GiB"`)
+	rootCmd.Flags().StringVarP(&inputFile, "input-file", "i", "", `file with a series to download per line: "<url> <ranges> [--language xx] [--scanlator yy]"`)
+	rootCmd.Flags().BoolVar(&settings.Force, "force", false, "download chapters again even if the output directory's ledger says they were already saved")
+	rootCmd.Flags().BoolVar(&settings.Upgrade, "upgrade", false, "check the chapters already downloaded for changes on the site (re-uploaded pages), downloading the changed ones again over their files")
+	rootCmd.Flags().BoolVar(&settings.UpgradeBackup, "upgrade-backup", false, "keep the files replaced by --upgrade in a .backup folder inside the output directory")
+	// the flags that shape how a series is downloaded are persistent, so the
+	// subcommands downloading series of their own (e.g. update) share them
+	rootCmd.PersistentFlags().Uint8VarP(&settings.MaxConcurrency.Chapters, "concurrency", "c", 5, "number of concurrent chapter downloads, hard-limited to 5")
+	rootCmd.PersistentFlags().Uint8VarP(&settings.MaxConcurrency.Pages, "concurrency-pages", "C", 10, "number of concurrent page downloads from a single host, hard-limited to 10")
+	rootCmd.PersistentFlags().StringVarP(&settings.Language, "language", "l", "", "only download the specified language")
+	rootCmd.PersistentFlags().StringVarP(&settings.Scanlator, "scanlator", "s", "", `only download the specified scanlation group, for sites hosting several versions of the same chapters ("all" downloads every group's)`)
+	rootCmd.PersistentFlags().StringVarP(&settings.FilenameTemplate, "filename-template", "t", packer.FilenameTemplateDefault, "template for the resulting filename")
+	rootCmd.PersistentFlags().StringVarP(&settings.Format, "format", "f", packer.FormatCBZ, "output format: "+packer.FormatsHelp())
+	rootCmd.PersistentFlags().StringVar(&settings.ReadingDirection, "reading-direction", packer.DirectionRTL, `reading direction of the epub and pdf pages: "rtl" (manga) or "ltr"`)
+	rootCmd.PersistentFlags().StringVar(&settings.ConvertImages, "convert-images", grabber.ConvertImagesDefault, `comma-separated source image formats to convert to jpeg for e-reader compatibility: "avif", "webp" or "none"`)
+	rootCmd.PersistentFlags().StringVar(&settings.Cover, "cover", packer.CoverNone, `where to put the series cover: "none", "bundle" (as the first page of each bundle), "file" (cover.jpg and folder.jpg in the output directory) or "both"`)
+	// the page options are the packer's, see readPageOptions
+	pageopts.AddFlags(rootCmd.PersistentFlags())
+	rootCmd.PersistentFlags().StringVar(&settings.OnExists, "on-exists", packer.OnExistsVersion, `what to do when an output file already exists: "version" (save it as " v2", " v3"...), "skip", "overwrite" or "fail"`)
+	rootCmd.PersistentFlags().BoolVar(&settings.BrowserVisible, "browser-visible", false, "open the browser window from the start (it opens automatically anyway when a headless attempt hits a challenge)")
+	rootCmd.PersistentFlags().Uint8VarP(&settings.Retry, "retry", "r", 1, "number of retries for failed page downloads, hard-limited to 3 (0 disables retrying)")
+	rootCmd.PersistentFlags().IntVar(&maxConnections, "max-connections", downloader.MaxConnectionsDefault, "max page downloads at once in total, across every chapter and series")
+	rootCmd.PersistentFlags().IntVar(&maxHostConnections, "max-connections-per-host", downloader.MaxHostConnectionsDefault, "max page downloads at once from a single host, across every chapter and series")
+	cobra.OnInitialize(func() {
+		downloader.SetLimits(maxConnections, maxHostConnections)
+	})
 	// set as persistent, so version command does not complain about the -o flag set via docker
 	rootCmd.PersistentFlags().StringVarP(&settings.OutputDir, "output-dir", "o", "./", "output directory for the downloaded files")
 }
@@ -437,30 +277,6 @@ func colorizeHelp(help string) string {
 	return help
 }
 
-func getRangesArg(args []string) string {
-	if len(args) == 1 {
-		return ""
-	}
-
-	if strings.HasPrefix(args[0], "http") {
-		return args[1]
-	}
-
-	return args[0]
-}
-
-func getUrlArg(args []string) string {
-	if len(args) == 1 {
-		return args[0]
-	}
-
-	if strings.HasPrefix(args[0], "http") {
-		return args[0]
-	}
-
-	return args[1]
-}
-
 // getTerminalWidth returns the current terminal width or a default value if it can't be determined
 func getTerminalWidth() int {
 	width, _, err := term.GetSize(int(syscall.Stdin))
@@ -0,0 +1,285 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/elboletaire/manga-downloader/grabber"
+	"github.com/elboletaire/manga-downloader/ledger"
+	"github.com/elboletaire/manga-downloader/ranges"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// seriesArg is a series given on the command line, along with its range
+type seriesArg struct {
+	URL string
+	// Range is the chapters to download, empty for every chapter
+	Range string
+}
+
+// parseSeriesArgs splits the command arguments into series, each url taking
+// the range following it, if any: "url1 1-10 url2 url3 5". As it's always
+// been possible with a single series, a range can also go first ("1-10 url"),
+// for the first url.
+func parseSeriesArgs(args []string) ([]seriesArg, error) {
+	series := []seriesArg{}
+	leading := ""
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "http") {
+			series = append(series, seriesArg{URL: arg})
+			continue
+		}
+
+		if _, err := ranges.Parse(arg); err != nil {
+			return nil, fmt.Errorf("invalid ranges %q: %w", arg, err)
+		}
+		switch {
+		case len(series) == 0 && leading == "":
+			leading = arg
+		case len(series) == 0 || series[len(series)-1].Range != "":
+			return nil, fmt.Errorf("unexpected ranges %q, a series takes a single range", arg)
+		default:
+			series[len(series)-1].Range = arg
+		}
+	}
+
+	if len(series) == 0 {
+		return nil, errors.New("no url given")
+	}
+	if leading != "" {
+		if series[0].Range != "" {
+			return nil, fmt.Errorf("unexpected ranges %q, a series takes a single range", leading)
+		}
+		series[0].Range = leading
+	}
+
+	return series, nil
+}
+
+// seriesJob is a series ready to be downloaded
+type seriesJob struct {
+	site     grabber.Site
+	cfg      grabber.Settings
+	url      string
+	title    string
+	chapters grabber.Filterables
+	lgr      *ledger.Ledger
+	upgrades []ledger.Entry
+	// shared is set when other series of the run are saved into the same
+	// dir, which isn't then any one's own folder: none of them gets a
+	// series.json or cover files, as they'd overwrite each other's (their
+	// ledger can't tell until every one recorded a chapter, or at all when
+	// one failed)
+	shared bool
+}
+
+// download downloads the job's chapters in the given session, then saves
+// its series.json and cover files
+func (j *seriesJob) download(d *downloadSession) (downloadResult, error) {
+	res, err := d.download(j.site, &j.cfg, j.url, j.title, j.chapters, j.lgr, j.upgrades)
+	if !j.shared {
+		saveSeriesMetadata(j.site, &j.cfg, j.url, j.lgr, res)
+	}
+	saveCoverFiles(&j.cfg, j.url, j.lgr, res, j.shared)
+
+	return res, err
+}
+
+// ledgers are the download ledgers of a run's output dirs, each opened once:
+// a ledger writes its whole file from the records it holds, so the series
+// saved into the same dir must share its ledger, or the last one to record a
+// chapter would drop every other series' records
+type ledgers map[string]*ledger.Ledger
+
+// open returns the ledger of dir, opening it the first time
+func (ls ledgers) open(dir string) (*ledger.Ledger, error) {
+	key := dir
+	if abs, err := filepath.Abs(dir); err == nil {
+		// the same dir can be written in several ways
+		key = abs
+	}
+	if lgr, ok := ls[key]; ok {
+		return lgr, nil
+	}
+
+	lgr, err := ledger.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	ls[key] = lgr
+
+	return lgr, nil
+}
+
+// prepareSeries identifies the site of a series and picks the chapters to
+// download: the ones in rng (every one, when empty) that the ledger doesn't
+// have yet, the ledger of its output dir being taken from lgrs. apply, if not
+// nil, sets the series' own settings on top of the command flags. The job is
+// nil when there's nothing to download, the summary telling why.
+func prepareSeries(cmd *cobra.Command, url, rng string, apply func(*grabber.Settings), lgrs ledgers) (*seriesJob, seriesSummary) {
+	sum := seriesSummary{Range: rng, Title: url}
+	job := &seriesJob{cfg: settings, url: url}
+
+	var err error
+	if job.site, err = newSite(url, &job.cfg, cmd); err != nil {
+		sum.Err = err
+		return nil, sum
+	}
+	// InitFlags (in newSite) reads the command flags back into cfg, so the
+	// series' own settings go on top of them afterwards
+	if apply != nil {
+		apply(&job.cfg)
+	}
+
+	if job.title, err = job.site.FetchTitle(); err != nil {
+		sum.Err = fmt.Errorf("fetching title: %w", err)
+		return nil, sum
+	}
+	sum.Title = job.title
+
+	chapters, err := fetchChapterList(job.site, &job.cfg)
+	if err != nil {
+		sum.Err = err
+		return nil, sum
+	}
+
+	if rng != "" {
+		// already validated while parsing the arguments
+		rngs, _ := ranges.Parse(rng)
+		chapters = chapters.FilterRanges(rngs)
+		if len(chapters) == 0 {
+			sum.Err = errors.New("no chapters found for the specified ranges")
+			return nil, sum
+		}
+	} else {
+		// bundles are named after the range
+		rng = fmt.Sprintf("%g-%g", chapters[0].GetNumber(), chapters[len(chapters)-1].GetNumber())
+	}
+	job.cfg.Range = rng
+
+	if job.lgr, err = lgrs.open(job.cfg.OutputDir); err != nil {
+		sum.Err = fmt.Errorf("reading the download ledger: %w", err)
+		return nil, sum
+	}
+	job.chapters, job.upgrades, sum.Skipped = pendingChapters(job.site, &job.cfg, url, chapters, job.lgr)
+	if len(job.chapters) == 0 {
+		return nil, sum
+	}
+
+	return job, sum
+}
+
+// seriesSummary is the outcome of downloading a single series, among others
+type seriesSummary struct {
+	// Range is the range of chapters asked for, empty for every chapter
+	Range string
+	// Title is the series title, or its URL if it couldn't be fetched
+	Title string
+	// Result is what was downloaded
+	Result downloadResult
+	// Skipped is the number of chapters the ledger says were already saved
+	Skipped int
+	// Err is the error that stopped the series from being downloaded, if any
+	Err error
+}
+
+// String returns the report line for the series
+func (s seriesSummary) String() string {
+	rng := s.Range
+	if rng == "" {
+		rng = "all"
+	}
+	prefix := fmt.Sprintf("- %s %s", s.Title, color.HiBlackString("(%s)", rng))
+	if s.Err != nil {
+		return fmt.Sprintf("%s: %s", prefix, color.RedString("error: %s", s.Err.Error()))
+	}
+
+	line := fmt.Sprintf("%s: %s", prefix, color.GreenString("%d chapters saved", len(s.Result.Chapters)))
+	if s.Skipped > 0 {
+		line += color.HiBlackString(", %d already downloaded", s.Skipped)
+	}
+	if s.Result.Failed > 0 {
+		line += color.RedString(", %d failed", s.Result.Failed)
+	}
+
+	return line
+}
+
+// failed tells whether anything of the series went wrong
+func (s seriesSummary) failed() bool {
+	return s.Err != nil || s.Result.Failed > 0
+}
+
+// printSummaries prints the report of every series, exiting with an error
+// code if any of them failed
+func printSummaries(heading string, summaries []seriesSummary) {
+	fmt.Println()
+	fmt.Println(heading)
+	failed := 0
+	for _, sum := range summaries {
+		fmt.Println(sum.String())
+		if sum.failed() {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		color.Red("%d of %d series failed", failed, len(summaries))
+		exit(1)
+	}
+}
+
+// runSeries downloads the several series given on the command line at once:
+// their chapters share the progress bars and the chapter concurrency budget,
+// so they don't download more chapters at once than a single series would.
+// Series without a range download every chapter, with no confirmation asked.
+func runSeries(cmd *cobra.Command, args []seriesArg) {
+	summaries := make([]seriesSummary, len(args))
+	jobs := make([]*seriesJob, len(args))
+	lgrs := ledgers{}
+	for i, arg := range args {
+		fmt.Printf("- %s %s\n", color.GreenString("fetching"), color.HiBlackString(arg.URL))
+		jobs[i], summaries[i] = prepareSeries(cmd, arg.URL, arg.Range, nil, lgrs)
+		if jobs[i] != nil {
+			// every series goes into the same --output-dir
+			jobs[i].shared = len(args) > 1
+		}
+	}
+
+	downloadJobs(jobs, summaries)
+	printSummaries("Summary:", summaries)
+}
+
+// downloadJobs downloads the prepared series (nil jobs being skipped) at once,
+// in a single session, setting the result of each on its summary
+func downloadJobs(jobs []*seriesJob, summaries []seriesSummary) {
+	budget := uint8(1)
+	for _, job := range jobs {
+		if job != nil {
+			// the sites' limits are clamped from the same flag, so the largest
+			// one is the flag's value unless every site asks for less
+			budget = max(budget, job.site.GetMaxConcurrency().Chapters)
+		}
+	}
+
+	d := newDownloadSession(budget)
+	wg := sync.WaitGroup{}
+	for i, job := range jobs {
+		if job == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, job *seriesJob) {
+			defer wg.Done()
+			summaries[i].Result, summaries[i].Err = job.download(d)
+		}(i, job)
+	}
+	wg.Wait()
+	d.wait()
+}
@@ -0,0 +1,248 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package cmd
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/elboletaire/manga-downloader/grabber"
+	"github.com/elboletaire/manga-downloader/ledger"
+	"github.com/elboletaire/manga-downloader/packer"
+)
+
+func TestParseSeriesArgs(t *testing.T) {
+	cases := []struct {
+		args []string
+		want []seriesArg
+	}{
+		{[]string{"https://a"}, []seriesArg{{URL: "https://a"}}},
+		{[]string{"https://a", "1-10"}, []seriesArg{{URL: "https://a", Range: "1-10"}}},
+		// the single series order that always worked
+		{[]string{"1-10", "https://a"}, []seriesArg{{URL: "https://a", Range: "1-10"}}},
+		{
+			[]string{"https://a", "1-10", "https://b", "https://c", "5"},
+			[]seriesArg{{URL: "https://a", Range: "1-10"}, {URL: "https://b"}, {URL: "https://c", Range: "5"}},
+		},
+	}
+	for _, c := range cases {
+		got, err := parseSeriesArgs(c.args)
+		if err != nil {
+			t.Errorf("%v: unexpected error: %s", c.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%v: expected %+v, got %+v", c.args, c.want, got)
+		}
+	}
+}
+
+func TestParseSeriesArgsErrors(t *testing.T) {
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"1-10"}, "no url given"},
+		{[]string{"https://a", "x"}, "invalid ranges"},
+		{[]string{"https://a", "1", "2"}, "a single range"},
+		{[]string{"1", "https://a", "2"}, "a single range"},
+	}
+	for _, c := range cases {
+		if _, err := parseSeriesArgs(c.args); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%v: expected an error about %q, got: %v", c.args, c.want, err)
+		}
+	}
+}
+
+// testSite is a grabber.Site serving chapters of a single page, downloaded
+// from pageURL, and the cover at coverURL, if any
+type testSite struct {
+	*grabber.Grabber
+	title    string
+	chapters int
+	pageURL  string
+	coverURL string
+}
+
+func (s *testSite) Test() (bool, error)         { return true, nil }
+func (s *testSite) FetchTitle() (string, error) { return s.title, nil }
+func (s *testSite) FetchChapters() (grabber.Filterables, []error) {
+	chapters := grabber.Filterables{}
+	for i := 1; i <= s.chapters; i++ {
+		chapters = append(chapters, &grabber.Chapter{Number: float64(i)})
+	}
+	return chapters, nil
+}
+func (s *testSite) FetchCover() (string, error) { return s.coverURL, nil }
+func (s *testSite) FetchMetadata() (*grabber.SeriesMetadata, error) {
+	return &grabber.SeriesMetadata{Title: s.title}, nil
+}
+func (s *testSite) FetchChapter(f grabber.Filterable) (*grabber.Chapter, error) {
+	return &grabber.Chapter{
+		Number:     f.GetNumber(),
+		PagesCount: 1,
+		Pages:      []grabber.Page{{Number: 1, URL: s.pageURL}},
+	}, nil
+}
+
+// testJob returns a job downloading every chapter of the series title into
+// dir, as prepareSeries would
+func testJob(t *testing.T, lgrs ledgers, dir, title, pageURL string) *seriesJob {
+	t.Helper()
+
+	job := &seriesJob{
+		url:   "https://example.com/" + title,
+		title: title,
+		cfg: grabber.Settings{
+			OutputDir:        dir,
+			Format:           packer.FormatCBZ,
+			FilenameTemplate: packer.FilenameTemplateDefault,
+			OnExists:         packer.OnExistsVersion,
+			MaxConcurrency:   grabber.MaxConcurrency{Chapters: 2, Pages: 1},
+		},
+	}
+	site := &testSite{title: title, chapters: 3, pageURL: pageURL}
+	site.Grabber = &grabber.Grabber{URL: job.url, Settings: &job.cfg}
+	job.site = site
+	job.chapters, _ = site.FetchChapters()
+
+	var err error
+	if job.lgr, err = lgrs.open(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	return job
+}
+
+// pageServer returns a server answering every request with a page, but for
+// /missing
+func pageServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, image.NewGray(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(buf.Bytes())
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// TestDownloadJobsShareLedger covers several series downloaded at once into
+// the same dir: every one's chapters must end up in its ledger
+func TestDownloadJobsShareLedger(t *testing.T) {
+	server := pageServer(t)
+
+	dir := t.TempDir()
+	lgrs := ledgers{}
+	// the same dir, written in another way
+	jobs := []*seriesJob{
+		testJob(t, lgrs, dir, "First", server.URL+"/page.png"),
+		testJob(t, lgrs, filepath.Join(dir, "."), "Second", server.URL+"/page.png"),
+	}
+	if jobs[0].lgr != jobs[1].lgr {
+		t.Fatal("expected the series saved into the same dir to share its ledger")
+	}
+
+	summaries := make([]seriesSummary, len(jobs))
+	downloadJobs(jobs, summaries)
+
+	lgr, err := ledger.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, job := range jobs {
+		if summaries[i].failed() || len(summaries[i].Result.Chapters) != 3 {
+			t.Errorf("%s: expected 3 chapters saved, got %+v", job.title, summaries[i])
+		}
+		if entries := lgr.Entries(job.url); len(entries) != 3 {
+			t.Errorf("%s: expected 3 chapters recorded, got %d", job.title, len(entries))
+		}
+	}
+}
+
+func TestDownloadJobsSeriesJSON(t *testing.T) {
+	server := pageServer(t)
+
+	// a dir several series are saved into is no one's own folder, even when
+	// only one of them gets to record its chapters
+	shared := t.TempDir()
+	lgrs := ledgers{}
+	jobs := []*seriesJob{
+		testJob(t, lgrs, shared, "First", server.URL+"/page.png"),
+		testJob(t, lgrs, shared, "Second", server.URL+"/missing"),
+	}
+	for _, job := range jobs {
+		job.shared = true
+	}
+	downloadJobs(jobs, make([]seriesSummary, len(jobs)))
+	if _, err := os.Stat(filepath.Join(shared, packer.SeriesFilename)); !os.IsNotExist(err) {
+		t.Errorf("expected no %s in a dir shared by several series", packer.SeriesFilename)
+	}
+
+	own := t.TempDir()
+	downloadJobs([]*seriesJob{testJob(t, lgrs, own, "Third", server.URL+"/page.png")}, make([]seriesSummary, 1))
+	if _, err := os.Stat(filepath.Join(own, packer.SeriesFilename)); err != nil {
+		t.Errorf("expected a %s in the series' own folder: %s", packer.SeriesFilename, err)
+	}
+}
+
+func TestDownloadJobsCoverFiles(t *testing.T) {
+	server := pageServer(t)
+	withCover := func(job *seriesJob) *seriesJob {
+		job.cfg.Cover = packer.CoverFile
+		job.site.(*testSite).coverURL = server.URL + "/cover.png"
+		return job
+	}
+
+	// the series of a dir several are saved into would replace each
+	// other's cover
+	shared := t.TempDir()
+	lgrs := ledgers{}
+	jobs := []*seriesJob{
+		withCover(testJob(t, lgrs, shared, "First", server.URL+"/page.png")),
+		withCover(testJob(t, lgrs, shared, "Second", server.URL+"/page.png")),
+	}
+	for _, job := range jobs {
+		job.shared = true
+	}
+	downloadJobs(jobs, make([]seriesSummary, len(jobs)))
+	for _, name := range packer.CoverFiles {
+		if _, err := os.Stat(filepath.Join(shared, name)); !os.IsNotExist(err) {
+			t.Errorf("expected no %s in a dir shared by several series", name)
+		}
+	}
+
+	// the series' own folder gets them, replacing stale ones
+	own := t.TempDir()
+	stale := filepath.Join(own, packer.CoverFiles[0])
+	if err := os.WriteFile(stale, []byte("an older cover"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	downloadJobs([]*seriesJob{withCover(testJob(t, lgrs, own, "Third", server.URL+"/page.png"))}, make([]seriesSummary, 1))
+	for _, name := range packer.CoverFiles {
+		data, err := os.ReadFile(filepath.Join(own, name))
+		if err != nil {
+			t.Errorf("expected a %s in the series' own folder: %s", name, err)
+			continue
+		}
+		if bytes.Equal(data, []byte("an older cover")) {
+			t.Errorf("expected the stale %s replaced", name)
+		}
+	}
+}
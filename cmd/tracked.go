@@ -0,0 +1,133 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/elboletaire/manga-downloader/grabber"
+)
+
+// trackedSeries is a series followed by the update command, along with the
+// settings it's downloaded with
+type trackedSeries struct {
+	URL              string `json:"url"`
+	Language         string `json:"language,omitempty"`
+	Scanlator        string `json:"scanlator,omitempty"`
+	Format           string `json:"format,omitempty"`
+	FilenameTemplate string `json:"filename_template,omitempty"`
+	// OutputDir is stored absolute, so update works from any directory
+	OutputDir string `json:"output_dir"`
+}
+
+// newTrackedSeries returns a tracked series for url, downloaded with cfg's
+// settings
+func newTrackedSeries(url string, cfg *grabber.Settings) (trackedSeries, error) {
+	dir, err := filepath.Abs(cfg.OutputDir)
+	if err != nil {
+		return trackedSeries{}, err
+	}
+
+	return trackedSeries{
+		URL:              url,
+		Language:         cfg.Language,
+		Scanlator:        cfg.Scanlator,
+		Format:           cfg.Format,
+		FilenameTemplate: cfg.FilenameTemplate,
+		OutputDir:        dir,
+	}, nil
+}
+
+// apply sets the series' own settings on cfg. Empty format and template keep
+// cfg's, so a hand-edited list doesn't need to spell out the defaults.
+func (t trackedSeries) apply(cfg *grabber.Settings) {
+	cfg.Language = t.Language
+	cfg.Scanlator = t.Scanlator
+	cfg.OutputDir = t.OutputDir
+	if t.Format != "" {
+		cfg.Format = t.Format
+	}
+	if t.FilenameTemplate != "" {
+		cfg.FilenameTemplate = t.FilenameTemplate
+	}
+}
+
+// trackedList is the list of series followed by the update command
+type trackedList struct {
+	path   string
+	Series []trackedSeries `json:"series"`
+}
+
+// defaultTrackedFile returns the default location of the tracked series list,
+// in the user's config dir, falling back to the current directory where there
+// isn't one (e.g. in the docker image, whose user has no home)
+func defaultTrackedFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "manga-downloader-tracked.json"
+	}
+
+	return filepath.Join(dir, "manga-downloader", "tracked.json")
+}
+
+// loadTrackedList reads the tracked series list at path; a missing file is
+// simply an empty list
+func loadTrackedList(path string) (*trackedList, error) {
+	list := &trackedList{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return list, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, list); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// add tracks a series, replacing the previous settings if it was already
+// tracked
+func (l *trackedList) add(series trackedSeries) {
+	for i, s := range l.Series {
+		if s.URL == series.URL {
+			l.Series[i] = series
+			return
+		}
+	}
+	l.Series = append(l.Series, series)
+}
+
+// remove stops tracking a series, reporting whether it was tracked at all
+func (l *trackedList) remove(url string) bool {
+	for i, s := range l.Series {
+		if s.URL == url {
+			l.Series = append(l.Series[:i], l.Series[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// save writes the list back to its file, creating its directory if needed
+func (l *trackedList) save() error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(l.path, data, 0644)
+}
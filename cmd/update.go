@@ -0,0 +1,193 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/elboletaire/manga-downloader/browser"
+	"github.com/elboletaire/manga-downloader/grabber"
+	"github.com/elboletaire/manga-downloader/ledger"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// trackedFile is the path of the tracked series list
+var trackedFile string
+
+// updateCmd represents the update command
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Downloads the new chapters of every tracked series",
+	Long: `Downloads the new chapters of every tracked series: the ones numbered above the highest chapter already saved in each series' output directory.
+
+Series are tracked with the "update add" command, which remembers the language, scanlator, format, filename template and output directory to download them with.`,
+	Example: colorizeHelp(`  manga-downloader update add --language es -o ~/manga/black-clover https://mangadex.org/title/e7eabe96-aa17-476f-b431-2497d5e9d060/black-clover
+
+Would track Black Clover from mangadex.org in Spanish, to be downloaded into ~/manga/black-clover.
+
+  manga-downloader update
+
+Would download the chapters released since the last update, for every tracked series.`),
+	Args: cobra.NoArgs,
+	Run:  runUpdate,
+}
+
+// updateAddCmd represents the update add command
+var updateAddCmd = &cobra.Command{
+	Use:   "add [flags] [url]...",
+	Short: "Tracks a series with the given download flags",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cerr(validateSettings(&settings), "Error: ")
+
+		list, err := loadTrackedList(trackedFile)
+		cerr(err, "Error reading the tracked series list: ")
+
+		for _, url := range args {
+			if !strings.HasPrefix(url, "http") {
+				color.Red("Error: invalid url %q", url)
+				exit(1)
+			}
+			series, err := newTrackedSeries(url, &settings)
+			cerr(err, "Error: ")
+			list.add(series)
+			fmt.Printf("- %s %s\n", color.GreenString("tracking"), color.HiBlackString(url))
+		}
+
+		cerr(list.save(), "Error saving the tracked series list: ")
+	},
+}
+
+// updateRemoveCmd represents the update remove command
+var updateRemoveCmd = &cobra.Command{
+	Use:   "remove [url]...",
+	Short: "Stops tracking a series",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		list, err := loadTrackedList(trackedFile)
+		cerr(err, "Error reading the tracked series list: ")
+
+		for _, url := range args {
+			if !list.remove(url) {
+				color.Yellow("- %s is not tracked", url)
+				continue
+			}
+			fmt.Printf("- %s %s\n", color.GreenString("untracked"), color.HiBlackString(url))
+		}
+
+		cerr(list.save(), "Error saving the tracked series list: ")
+	},
+}
+
+// updateListCmd represents the update list command
+var updateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the tracked series",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		list, err := loadTrackedList(trackedFile)
+		cerr(err, "Error reading the tracked series list: ")
+
+		for _, s := range list.Series {
+			fmt.Println(s.URL)
+			fmt.Println(color.HiBlackString("  output dir: %s", s.OutputDir))
+			if s.Language != "" {
+				fmt.Println(color.HiBlackString("  language: %s", s.Language))
+			}
+			if s.Scanlator != "" {
+				fmt.Println(color.HiBlackString("  scanlator: %s", s.Scanlator))
+			}
+		}
+	},
+}
+
+// runUpdate downloads the new chapters of every tracked series, one series
+// after the other, and ends with a summary of what was new for each
+func runUpdate(cmd *cobra.Command, args []string) {
+	// ensure the shared Chrome process (if any) is killed on exit
+	defer browser.Close()
+	browser.SetVisible(settings.BrowserVisible)
+	cerr(readPageOptions(cmd), "Error: ")
+
+	list, err := loadTrackedList(trackedFile)
+	cerr(err, "Error reading the tracked series list: ")
+
+	if len(list.Series) == 0 {
+		color.Yellow("No series tracked yet, add one with: manga-downloader update add <url>")
+		exit(0)
+	}
+
+	summaries := []seriesSummary{}
+	for _, series := range list.Series {
+		summaries = append(summaries, updateSeries(cmd, series))
+	}
+
+	printSummaries("Update summary:", summaries)
+}
+
+// updateSeries downloads the chapters of a tracked series numbered above the
+// highest one saved in its output directory (every chapter, for a series
+// that was never downloaded)
+func updateSeries(cmd *cobra.Command, series trackedSeries) seriesSummary {
+	sum := seriesSummary{Title: series.URL}
+
+	cfg := settings
+	s, err := newSite(series.URL, &cfg, cmd)
+	if err != nil {
+		sum.Err = err
+		return sum
+	}
+	// InitFlags (in newSite) reads the command flags back into cfg, so the
+	// series' own settings go on top of them afterwards
+	series.apply(&cfg)
+	if sum.Err = validateSettings(&cfg); sum.Err != nil {
+		return sum
+	}
+
+	title, err := s.FetchTitle()
+	if err != nil {
+		sum.Err = fmt.Errorf("fetching title: %w", err)
+		return sum
+	}
+	sum.Title = title
+
+	chapters, err := fetchChapterList(s, &cfg)
+	if err != nil {
+		sum.Err = err
+		return sum
+	}
+
+	if sum.Err = os.MkdirAll(cfg.OutputDir, 0755); sum.Err != nil {
+		return sum
+	}
+	lgr, err := ledger.Open(cfg.OutputDir)
+	if err != nil {
+		sum.Err = fmt.Errorf("reading the download ledger: %w", err)
+		return sum
+	}
+
+	if highest, ok := lgr.Highest(series.URL); ok {
+		sum.Range = fmt.Sprintf("after %g", highest)
+		chapters = chapters.Filter(func(c grabber.Filterable) bool {
+			return c.GetNumber() > highest
+		})
+	}
+	if len(chapters) == 0 {
+		return sum
+	}
+
+	fmt.Printf("- %s: %s\n", title, color.GreenString("downloading %d new chapters", len(chapters)))
+	cfg.Range = fmt.Sprintf("%g-%g", chapters[0].GetNumber(), chapters[len(chapters)-1].GetNumber())
+	sum.Result, sum.Err = downloadChapters(s, &cfg, series.URL, title, chapters, lgr, nil)
+
+	return sum
+}
+
+func init() {
+	updateCmd.PersistentFlags().StringVar(&trackedFile, "tracked-file", defaultTrackedFile(), "file keeping the list of tracked series")
+	updateCmd.AddCommand(updateAddCmd, updateRemoveCmd, updateListCmd)
+	rootCmd.AddCommand(updateCmd)
+}
@@ -0,0 +1,55 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/elboletaire/manga-downloader/grabber"
+)
+
+func TestTrackedListRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", "tracked.json")
+
+	list, err := loadTrackedList(path)
+	if err != nil {
+		t.Fatalf("a missing list must load empty, got: %s", err)
+	}
+
+	list.add(trackedSeries{URL: "https://example.com/a", Language: "es", OutputDir: "/a"})
+	list.add(trackedSeries{URL: "https://example.com/b", OutputDir: "/b"})
+	// re-adding a series replaces its settings instead of tracking it twice
+	list.add(trackedSeries{URL: "https://example.com/a", Language: "en", OutputDir: "/a"})
+	if err := list.save(); err != nil {
+		t.Fatalf("unexpected error saving: %s", err)
+	}
+
+	reloaded, err := loadTrackedList(path)
+	if err != nil {
+		t.Fatalf("unexpected error reloading: %s", err)
+	}
+	if len(reloaded.Series) != 2 || reloaded.Series[0].Language != "en" {
+		t.Fatalf("expected 2 series with a's settings replaced, got %+v", reloaded.Series)
+	}
+
+	if !reloaded.remove("https://example.com/a") || len(reloaded.Series) != 1 {
+		t.Errorf("expected a to be removed, got %+v", reloaded.Series)
+	}
+	if reloaded.remove("https://example.com/a") {
+		t.Error("removing an untracked series must report it")
+	}
+}
+
+func TestTrackedSeriesApply(t *testing.T) {
+	cfg := grabber.Settings{Language: "fr", Format: "cbz", FilenameTemplate: "{{.Series}}", OutputDir: "./"}
+	trackedSeries{URL: "https://example.com/a", Format: "raw", OutputDir: "/a"}.apply(&cfg)
+
+	// the series' language applies even when empty: it means "every language"
+	if cfg.Language != "" || cfg.Format != "raw" || cfg.OutputDir != "/a" {
+		t.Errorf("expected the series settings to apply, got %+v", cfg)
+	}
+	if cfg.FilenameTemplate != "{{.Series}}" {
+		t.Errorf("an empty template must keep the flag's, got %q", cfg.FilenameTemplate)
+	}
+}
@@ -26,10 +26,17 @@ type File struct {
 // ProgressCallback is a function type for progress updates with optional error
 type ProgressCallback func(page, progress int, err error)
 
-// FetchChapter downloads all the pages of a chapter
-func FetchChapter(site grabber.Site, chapter *grabber.Chapter, onprogress ProgressCallback) ([]*File, error) {
+// FetchChapter downloads all the pages of a chapter. Pages already in spool
+// (from a previous, interrupted attempt) are reused instead of downloaded
+// again, and every freshly downloaded page is spooled as soon as it arrives;
+// spool may be nil to keep everything in memory only.
+//
+// Every page download takes a slot of the process-wide pool (see Pool), shared
+// with every other chapter downloading at the same time, the site's own page
+// concurrency limiting its downloads from each host.
+func FetchChapter(site grabber.Site, chapter *grabber.Chapter, spool *Spool, onprogress ProgressCallback) ([]*File, error) {
 	wg := sync.WaitGroup{}
-	guard := make(chan struct{}, site.GetMaxConcurrency().Pages)
+	limit := int(site.GetMaxConcurrency().Pages)
 	errChan := make(chan error, 1)
 	done := make(chan bool)
 	// A local (not the named return) so page goroutines write into a slice that
@@ -41,10 +48,17 @@ func FetchChapter(site grabber.Site, chapter *grabber.Chapter, onprogress Progre
 	res := make([]*File, len(chapter.Pages)) // Pre-allocate slice with correct size
 
 	for i, page := range chapter.Pages {
-		guard <- struct{}{}
+		if file, ok := spool.Load(uint(page.Number)); ok {
+			res[i] = file
+			onprogress(1, i, nil)
+			continue
+		}
+
+		pool.Acquire(page.URL, limit)
 		wg.Add(1)
 		go func(page grabber.Page, idx int) {
 			defer wg.Done()
+			defer pool.Release(page.URL)
 
 			file, err := FetchFile(http.RequestParams{
 				URL:     page.URL,
@@ -57,13 +71,16 @@ func FetchChapter(site grabber.Site, chapter *grabber.Chapter, onprogress Progre
 					onprogress(idx, idx, err)
 				default:
 				}
-				<-guard
 				return
 			}
 
+			// spooling is best effort: a page that can't be written to disk
+			// is still good in memory, it would just be downloaded again if
+			// this chapter had to be resumed
+			spool.Store(file)
+
 			res[idx] = file         // Store file directly in pre-allocated slice
 			onprogress(1, idx, nil) // Progress by 1 page at a time
-			<-guard
 		}(page, i)
 	}
 
@@ -74,10 +91,8 @@ func FetchChapter(site grabber.Site, chapter *grabber.Chapter, onprogress Progre
 
 	select {
 	case err := <-errChan:
-		close(guard)
 		return nil, err
 	case <-done:
-		close(guard)
 	}
 
 	// sort files by page number
@@ -116,6 +131,17 @@ func FetchFile(params http.RequestParams, page uint, retries uint8, transform fu
 	}
 }
 
+// FetchPooled gets an online file as FetchFile does, taking a slot of the
+// process-wide pool (see Pool) meanwhile, as the chapters' pages do: for the
+// files downloaded on their own, like a series' cover, so they count against
+// the same per-host cap
+func FetchPooled(params http.RequestParams, page uint, retries uint8, transform func([]byte) ([]byte, error)) (*File, error) {
+	pool.Acquire(params.URL, 0)
+	defer pool.Release(params.URL)
+
+	return FetchFile(params, page, retries, transform)
+}
+
 // fetchFileOnce performs a single GET + body read attempt
 func fetchFileOnce(params http.RequestParams) (data []byte, err error) {
 	body, err := http.Get(params)
@@ -176,3 +176,37 @@ func TestFetchFile_RetriesOnTransformFailure(t *testing.T) {
 		t.Errorf("expected 2 requests (transform failure re-fetches), got %d", got)
 	}
 }
+
+func TestFetchPooled_WaitsForPoolSlot(t *testing.T) {
+	original := pool
+	pool = NewPool(10, 1)
+	t.Cleanup(func() {
+		pool = original
+	})
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte("cover"))
+	}))
+	defer server.Close()
+
+	// a page of the same host is downloading
+	pool.Acquire(server.URL+"/page.jpg", 0)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		file, err := FetchPooled(mangahttp.RequestParams{URL: server.URL + "/cover.jpg"}, 0, 0, nil)
+		if err != nil || string(file.Data) != "cover" {
+			t.Errorf("unexpected result: %v, %v", file, err)
+		}
+	}()
+
+	if isDone(done) || atomic.LoadInt32(&requests) != 0 {
+		t.Fatal("expected the download to wait for a slot of its host")
+	}
+	pool.Release(server.URL + "/page.jpg")
+	if !isDone(done) {
+		t.Error("expected the download to start once the slot is freed")
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package downloader
+
+import (
+	"net/url"
+	"sync"
+)
+
+// Default limits of the process-wide pool (see SetLimits)
+const (
+	MaxConnectionsDefault     = 50
+	MaxHostConnectionsDefault = 20
+)
+
+// pool is the process-wide pool every page download goes through
+var pool = NewPool(MaxConnectionsDefault, MaxHostConnectionsDefault)
+
+// SetLimits sets the limits of the process-wide download pool: the page
+// downloads running at once in total, and against any single host. Zero
+// leaves a limit as it is. It must be called before any download starts.
+func SetLimits(total, perHost int) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if total > 0 {
+		pool.total = total
+	}
+	if perHost > 0 {
+		pool.perHost = perHost
+	}
+}
+
+// Pool bounds the downloads running at once, in total and per host, so
+// several chapters (or several series) downloading together don't hammer the
+// same CDN from dozens of goroutines. Slots are handed out first come, first
+// served, skipping only the waiters whose host is full, so chapters share the
+// pool fairly and one busy host doesn't hold back the others.
+//
+// It's the only place page downloads are limited: a site's own page
+// concurrency is a per-host limit of its downloads (see Acquire), rather than
+// a cap of each chapter stacked on top of the pool's.
+type Pool struct {
+	mu      sync.Mutex
+	total   int
+	perHost int
+	running int
+	hosts   map[string]int
+	queue   []*poolWaiter
+}
+
+// poolWaiter is a download waiting for a slot
+type poolWaiter struct {
+	host  string
+	limit int
+	ready chan struct{}
+}
+
+// NewPool returns a pool running up to total downloads at once, and up to
+// perHost against any single host
+func NewPool(total, perHost int) *Pool {
+	return &Pool{
+		total:   max(total, 1),
+		perHost: max(perHost, 1),
+		hosts:   map[string]int{},
+	}
+}
+
+// Acquire blocks until there's a slot for a download from rawURL's host.
+// limit, when positive, lowers the pool's per-host limit for this download:
+// it waits while that many downloads from its host are running (e.g. a
+// site's own page concurrency). Every Acquire must be followed by a Release
+// with the same url.
+func (p *Pool) Acquire(rawURL string, limit int) {
+	host := hostOf(rawURL)
+
+	p.mu.Lock()
+	// anyone queued is waiting for a full host, or for the total to free up
+	// (then this one doesn't fit either): going first can't jump the queue
+	if p.fits(host, limit) {
+		p.take(host)
+		p.mu.Unlock()
+		return
+	}
+	w := &poolWaiter{host: host, limit: limit, ready: make(chan struct{})}
+	p.queue = append(p.queue, w)
+	p.mu.Unlock()
+
+	<-w.ready
+}
+
+// Release frees the slot of a download from rawURL's host, handing it over to
+// the first waiter that fits
+func (p *Pool) Release(rawURL string) {
+	host := hostOf(rawURL)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.running--
+	if p.hosts[host]--; p.hosts[host] <= 0 {
+		delete(p.hosts, host)
+	}
+
+	queue := p.queue[:0]
+	for _, w := range p.queue {
+		if p.fits(w.host, w.limit) {
+			p.take(w.host)
+			close(w.ready)
+			continue
+		}
+		queue = append(queue, w)
+	}
+	p.queue = queue
+}
+
+// fits tells whether a download from host, limited to limit downloads from it
+// at once (when positive), can start now. Callers must hold mu.
+func (p *Pool) fits(host string, limit int) bool {
+	perHost := p.perHost
+	if limit > 0 {
+		perHost = min(perHost, limit)
+	}
+
+	return p.running < p.total && p.hosts[host] < perHost
+}
+
+// take marks a download from host as running. Callers must hold mu.
+func (p *Pool) take(host string) {
+	p.running++
+	p.hosts[host]++
+}
+
+// hostOf returns the host of rawURL, or rawURL itself if it can't be parsed
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	return u.Host
+}
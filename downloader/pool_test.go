@@ -0,0 +1,113 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package downloader
+
+import (
+	"testing"
+	"time"
+)
+
+// acquired starts an Acquire in the background, returning a channel closed
+// once it gets its slot
+func acquired(p *Pool, url string) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		p.Acquire(url, 0)
+		close(done)
+	}()
+	return done
+}
+
+// isDone tells whether ch is closed within a short wait
+func isDone(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	case <-time.After(50 * time.Millisecond):
+		return false
+	}
+}
+
+func TestPoolPerHostLimit(t *testing.T) {
+	p := NewPool(10, 2)
+	p.Acquire("https://a.example/1.jpg", 0)
+	p.Acquire("https://a.example/2.jpg", 0)
+
+	third := acquired(p, "https://a.example/3.jpg")
+	if isDone(third) {
+		t.Fatal("expected a third download from the same host to wait")
+	}
+	// a full host doesn't hold back the others
+	if !isDone(acquired(p, "https://b.example/1.jpg")) {
+		t.Fatal("expected another host to get a slot right away")
+	}
+
+	p.Release("https://a.example/1.jpg")
+	if !isDone(third) {
+		t.Error("expected the waiting download to get the freed slot")
+	}
+}
+
+func TestPoolTotalLimit(t *testing.T) {
+	p := NewPool(2, 2)
+	p.Acquire("https://a.example/1.jpg", 0)
+	p.Acquire("https://b.example/1.jpg", 0)
+
+	waiting := acquired(p, "https://c.example/1.jpg")
+	if isDone(waiting) {
+		t.Fatal("expected a download past the total limit to wait")
+	}
+
+	p.Release("https://a.example/1.jpg")
+	if !isDone(waiting) {
+		t.Error("expected the waiting download to get the freed slot")
+	}
+}
+
+func TestPoolIsFirstComeFirstServed(t *testing.T) {
+	p := NewPool(1, 1)
+	p.Acquire("https://a.example/0.jpg", 0)
+
+	first := acquired(p, "https://a.example/1.jpg")
+	isDone(first) // let it queue up first
+	second := acquired(p, "https://b.example/1.jpg")
+	if isDone(second) {
+		t.Fatal("expected the second download to queue up")
+	}
+
+	p.Release("https://a.example/0.jpg")
+	if !isDone(first) {
+		t.Fatal("expected the first waiter to get the slot")
+	}
+	if isDone(second) {
+		t.Error("expected the second waiter to keep waiting its turn")
+	}
+}
+
+func TestPoolDownloadLimit(t *testing.T) {
+	p := NewPool(10, 5)
+	p.Acquire("https://a.example/1.jpg", 1)
+
+	// the download's own limit is lower than the pool's
+	limited := make(chan struct{})
+	go func() {
+		p.Acquire("https://a.example/2.jpg", 1)
+		close(limited)
+	}()
+	if isDone(limited) {
+		t.Fatal("expected a download past its own limit to wait")
+	}
+	// the limit is the download's alone
+	if !isDone(acquired(p, "https://a.example/3.jpg")) {
+		t.Fatal("expected a download without a limit of its own to get a slot right away")
+	}
+
+	p.Release("https://a.example/1.jpg")
+	if isDone(limited) {
+		t.Fatal("expected the limited download to keep waiting while its host has another running")
+	}
+	p.Release("https://a.example/3.jpg")
+	if !isDone(limited) {
+		t.Error("expected the limited download to get a slot once its host is under its limit")
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package downloader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/elboletaire/manga-downloader/grabber"
+)
+
+// spoolDir is where every chapter's spool lives. It's a package-level var so
+// tests can point it somewhere disposable.
+var spoolDir = defaultSpoolDir()
+
+// defaultSpoolDir returns the spool location: the user cache dir, so spooled
+// pages survive a reboot, falling back to the temp dir where there's none
+func defaultSpoolDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+
+	return filepath.Join(dir, "manga-downloader", "spool")
+}
+
+// Spool keeps a chapter's downloaded pages on disk while it's being
+// downloaded, so a chapter interrupted halfway (a page failing for good, or
+// the process being killed) resumes on the next run with only the missing
+// pages, instead of starting over. A nil *Spool is valid and spools nothing.
+type Spool struct {
+	dir string
+}
+
+// NewSpool returns the spool of a chapter of the given series, downloaded
+// into outputdir. Its directory is derived from the output directory, the
+// series and the chapter's number, language, scanlator and title, so the same
+// chapter lands in the same spool from one run to the next while distinct
+// releases sharing a number don't mix their pages, and two runs saving the
+// same chapter into different directories don't remove each other's spool.
+func NewSpool(outputdir, series string, chapter *grabber.Chapter) *Spool {
+	if abs, err := filepath.Abs(outputdir); err == nil {
+		outputdir = abs
+	}
+	key := fmt.Sprintf("%s\x00%s\x00%g\x00%s\x00%s\x00%s", outputdir, series, chapter.GetNumber(), chapter.Language, chapter.Scanlator, chapter.GetTitle())
+	sum := sha256.Sum256([]byte(key))
+
+	return &Spool{dir: filepath.Join(spoolDir, hex.EncodeToString(sum[:12]))}
+}
+
+// Load returns the spooled page with the given number, if any
+func (s *Spool) Load(page uint) (*File, bool) {
+	if s == nil {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(s.path(page))
+	if err != nil {
+		return nil, false
+	}
+
+	return &File{Data: data, Page: page}, true
+}
+
+// Store spools a downloaded page. It's written to a temporary file first and
+// renamed into place, so a process killed mid-write never leaves a truncated
+// page behind to be reused on the next run.
+func (s *Spool) Store(file *File) error {
+	if s == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, "page-*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(file.Data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path(file.Page))
+}
+
+// Remove deletes the spool and every page in it, once the chapter is safely
+// packed
+func (s *Spool) Remove() error {
+	if s == nil {
+		return nil
+	}
+
+	return os.RemoveAll(s.dir)
+}
+
+// path returns the spooled file of the given page
+func (s *Spool) path(page uint) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%04d", page))
+}
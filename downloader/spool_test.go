@@ -0,0 +1,148 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package downloader
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/elboletaire/manga-downloader/grabber"
+	"github.com/spf13/cobra"
+)
+
+// withTempSpoolDir points the spool at a per-test directory
+func withTempSpoolDir(t *testing.T) {
+	t.Helper()
+	original := spoolDir
+	spoolDir = t.TempDir()
+	t.Cleanup(func() {
+		spoolDir = original
+	})
+}
+
+// fakeSite is a minimal grabber.Site, enough for FetchChapter
+type fakeSite struct{}
+
+func (fakeSite) InitFlags(cmd *cobra.Command)                              {}
+func (fakeSite) Test() (bool, error)                                       { return true, nil }
+func (fakeSite) FetchChapters() (grabber.Filterables, []error)             { return nil, nil }
+func (fakeSite) FetchChapter(grabber.Filterable) (*grabber.Chapter, error) { return nil, nil }
+func (fakeSite) FetchTitle() (string, error)                               { return "Test", nil }
+func (fakeSite) BaseUrl() string                                           { return "https://example.com" }
+func (fakeSite) GetURL() string                                            { return "https://example.com/series" }
+func (fakeSite) GetFilenameTemplate() string                               { return "" }
+func (fakeSite) GetFormat() string                                         { return "cbz" }
+func (fakeSite) GetReadingDirection() string                               { return "rtl" }
+func (fakeSite) GetConvertImages() grabber.ConvertFormats                  { return nil }
+func (fakeSite) GetOnExists() string                                       { return "version" }
+func (fakeSite) GetPreferredLanguage() string                              { return "" }
+func (fakeSite) GetPreferredScanlator() string                             { return "" }
+func (fakeSite) GetRetries() uint8                                         { return 0 }
+func (fakeSite) GetMaxConcurrency() grabber.MaxConcurrency {
+	// one page at a time, so a page is always spooled before the next starts
+	return grabber.MaxConcurrency{Chapters: 1, Pages: 1}
+}
+
+func TestSpoolRoundTrip(t *testing.T) {
+	withTempSpoolDir(t)
+
+	spool := NewSpool("out", "https://example.com/manga", &grabber.Chapter{Number: 1})
+	if _, ok := spool.Load(1); ok {
+		t.Fatal("expected an empty spool")
+	}
+
+	if err := spool.Store(&File{Data: []byte("page one"), Page: 1}); err != nil {
+		t.Fatalf("unexpected error storing: %s", err)
+	}
+	file, ok := spool.Load(1)
+	if !ok || string(file.Data) != "page one" || file.Page != 1 {
+		t.Errorf("expected page 1 back, got %+v", file)
+	}
+
+	// the same chapter maps to the same spool from one run to the next...
+	if _, ok := NewSpool("out", "https://example.com/manga", &grabber.Chapter{Number: 1}).Load(1); !ok {
+		t.Error("expected the same chapter to share its spool")
+	}
+	// ...but another release of the same number doesn't
+	if _, ok := NewSpool("out", "https://example.com/manga", &grabber.Chapter{Number: 1, Language: "es"}).Load(1); ok {
+		t.Error("expected another release of the chapter to get its own spool")
+	}
+	// ...nor does the same chapter saved somewhere else, which a run removing
+	// its spool once packed would otherwise pull from under the other
+	if _, ok := NewSpool("elsewhere", "https://example.com/manga", &grabber.Chapter{Number: 1}).Load(1); ok {
+		t.Error("expected the chapter saved into another directory to get its own spool")
+	}
+	if _, ok := NewSpool("./out", "https://example.com/manga", &grabber.Chapter{Number: 1}).Load(1); !ok {
+		t.Error("expected the same directory, written another way, to share the spool")
+	}
+
+	if err := spool.Remove(); err != nil {
+		t.Fatalf("unexpected error removing: %s", err)
+	}
+	if _, err := os.Stat(spool.dir); !os.IsNotExist(err) {
+		t.Error("expected the spool directory to be gone")
+	}
+}
+
+func TestNilSpool(t *testing.T) {
+	var spool *Spool
+	if _, ok := spool.Load(1); ok {
+		t.Error("a nil spool must never have pages")
+	}
+	if err := spool.Store(&File{Page: 1}); err != nil {
+		t.Errorf("a nil spool must store nothing silently, got: %s", err)
+	}
+	if err := spool.Remove(); err != nil {
+		t.Errorf("a nil spool must remove nothing silently, got: %s", err)
+	}
+}
+
+// TestFetchChapterResumesFromSpool covers the point of spooling: a chapter
+// whose download failed halfway only requests the pages it's missing the
+// next time around
+func TestFetchChapterResumesFromSpool(t *testing.T) {
+	withTempSpoolDir(t)
+
+	var requests int32
+	failing := int32(1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path == "/3" && atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("page" + r.URL.Path))
+	}))
+	defer server.Close()
+
+	chapter := &grabber.Chapter{Number: 1}
+	for i := 1; i <= 3; i++ {
+		chapter.Pages = append(chapter.Pages, grabber.Page{Number: int64(i), URL: fmt.Sprintf("%s/%d", server.URL, i)})
+	}
+	spool := NewSpool(t.TempDir(), server.URL, chapter)
+	noop := func(page, progress int, err error) {}
+
+	if _, err := FetchChapter(fakeSite{}, chapter, spool, noop); err == nil {
+		t.Fatal("expected the first attempt to fail on page 3")
+	}
+
+	atomic.StoreInt32(&failing, 0)
+	atomic.StoreInt32(&requests, 0)
+	files, err := FetchChapter(fakeSite{}, chapter, spool, noop)
+	if err != nil {
+		t.Fatalf("expected the second attempt to succeed, got: %s", err)
+	}
+
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("expected only the missing page to be requested, got %d requests", got)
+	}
+	for i, f := range files {
+		if want := fmt.Sprintf("page/%d", i+1); string(f.Data) != want {
+			t.Errorf("page %d = %q, want %q", i+1, f.Data, want)
+		}
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/url"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -25,6 +27,8 @@ type Atsumaru struct {
 	// chapters caches the raw api chapter list, keyed by manga id, so
 	// FetchTitle and FetchChapters can share a single api call
 	info *atsumaruMangaInfo
+	// page caches the manga page api's details (see mangaPage)
+	page *atsumaruMangaPage
 	// scanlators caches the scanId -> name mapping, which lives only in the
 	// series page html; nil once fetched means the page shape changed
 	scanlators     []atsumaruScanlator
@@ -63,6 +67,51 @@ func (a *Atsumaru) FetchTitle() (string, error) {
 	return a.title, nil
 }
 
+// FetchCover returns the URL of the manga's poster
+func (a *Atsumaru) FetchCover() (string, error) {
+	page, err := a.mangaPage()
+	if err != nil || page.MangaPage.Poster.Image == "" {
+		return "", err
+	}
+
+	// posters are given by path, like the pages, relative to the static files
+	base, _ := url.Parse(a.BaseUrl() + "/static/")
+	poster, err := url.Parse(page.MangaPage.Poster.Image)
+	if err != nil {
+		return "", err
+	}
+
+	return base.ResolveReference(poster).String(), nil
+}
+
+// FetchMetadata returns the manga's details
+func (a *Atsumaru) FetchMetadata() (*SeriesMetadata, error) {
+	page, err := a.mangaPage()
+	if err != nil {
+		return nil, err
+	}
+	title, err := a.FetchTitle()
+	if err != nil {
+		return nil, err
+	}
+
+	mp := page.MangaPage
+	meta := &SeriesMetadata{
+		Title:       title,
+		AltTitles:   appendUnique(nil, append([]string{mp.Title, mp.EnglishTitle}, mp.OtherNames...)...),
+		Description: strings.TrimSpace(mp.Synopsis),
+		Status:      atsumaruStatuses[strings.ToLower(mp.Status)],
+		Language:    "en",
+		URL:         a.URL,
+	}
+	meta.AltTitles = slices.DeleteFunc(meta.AltTitles, func(t string) bool { return t == title })
+	for _, author := range mp.Authors {
+		meta.Authors = appendUnique(meta.Authors, author.Name)
+	}
+
+	return meta, nil
+}
+
 // FetchChapters returns the chapters of the manga
 func (a *Atsumaru) FetchChapters() (chapters Filterables, errs []error) {
 	info, err := a.mangaInfo()
@@ -98,8 +147,9 @@ func (a *Atsumaru) FetchChapters() (chapters Filterables, errs []error) {
 		}
 		chapters = append(chapters, &AtsumaruChapter{
 			Chapter{
-				Number: c.Number,
-				Title:  title,
+				Number:    c.Number,
+				Title:     title,
+				Scanlator: name,
 			},
 			c.Id,
 		})
@@ -286,6 +336,7 @@ func (a *Atsumaru) FetchChapter(f Filterable) (*Chapter, error) {
 		Title:      f.GetTitle(),
 		Number:     f.GetNumber(),
 		Language:   "en",
+		Scanlator:  achap.Scanlator,
 		PagesCount: int64(len(feed.ReadChapter.Pages)),
 	}
 	for i, p := range feed.ReadChapter.Pages {
@@ -339,6 +390,36 @@ func (a *Atsumaru) mangaInfo() (*atsumaruMangaInfo, error) {
 	return info, nil
 }
 
+// mangaPage fetches (and caches) the manga page api's details: the series
+// details the info api leaves out (poster, synopsis...)
+func (a *Atsumaru) mangaPage() (*atsumaruMangaPage, error) {
+	if a.page != nil {
+		return a.page, nil
+	}
+
+	mangaId, err := a.mangaId()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := http.GetText(http.RequestParams{
+		URL:     "https://atsu.moe/api/manga/page?id=" + mangaId,
+		Referer: a.URL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := &atsumaruMangaPage{}
+	if err = json.Unmarshal([]byte(body), page); err != nil {
+		return nil, err
+	}
+
+	a.page = page
+
+	return page, nil
+}
+
 // atsumaruScanlator is a scanlation group as embedded in the series page
 type atsumaruScanlator struct {
 	Id   string `json:"id"`
@@ -351,6 +432,36 @@ type atsumaruMangaInfo struct {
 	Chapters []atsumaruInfoChapter `json:"chapters"`
 }
 
+// atsumaruMangaPage is the JSON feed for the manga page api
+type atsumaruMangaPage struct {
+	MangaPage struct {
+		Title        string   `json:"title"`
+		EnglishTitle string   `json:"englishTitle"`
+		OtherNames   []string `json:"otherNames"`
+		Synopsis     string   `json:"synopsis"`
+		Authors      []struct {
+			Name string `json:"name"`
+		} `json:"authors"`
+		// Status is a key of atsumaruStatuses, capitalized
+		Status string `json:"status"`
+		Poster struct {
+			// Image is the poster's path
+			Image string `json:"image"`
+		} `json:"poster"`
+	} `json:"mangaPage"`
+}
+
+// atsumaruStatuses maps the (lowercased) manga statuses to SeriesMetadata
+// statuses
+var atsumaruStatuses = map[string]string{
+	"ongoing":   StatusOngoing,
+	"completed": StatusCompleted,
+	"hiatus":    StatusHiatus,
+	"cancelled": StatusCancelled,
+	"canceled":  StatusCancelled,
+	"dropped":   StatusCancelled,
+}
+
 // atsumaruInfoChapter is a single chapter in the manga info feed, which mixes
 // every scanlation group's uploads together
 type atsumaruInfoChapter struct {
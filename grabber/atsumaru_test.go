@@ -3,6 +3,7 @@
 package grabber
 
 import (
+	"encoding/json"
 	"strconv"
 	"strings"
 	"testing"
@@ -221,3 +222,49 @@ func TestAtsumaruFetchChaptersAllKeepsDuplicateNumbers(t *testing.T) {
 		t.Errorf("both chapter 1s share the title %q, they'd collide when packed", ones[0].GetTitle())
 	}
 }
+
+func TestAtsumaruFetchCoverResolvesPoster(t *testing.T) {
+	a := newTestAtsumaru("")
+	for poster, want := range map[string]string{
+		"posters/exqmE.webp":        "https://atsu.moe/static/posters/exqmE.webp",
+		"/static/posters/exqmE.jpg": "https://atsu.moe/static/posters/exqmE.jpg",
+		"":                          "",
+	} {
+		a.page = &atsumaruMangaPage{}
+		a.page.MangaPage.Poster.Image = poster
+		if got, err := a.FetchCover(); got != want || err != nil {
+			t.Errorf("FetchCover() with poster %q = %q, %v; want %q", poster, got, err, want)
+		}
+	}
+}
+
+func TestAtsumaruFetchMetadata(t *testing.T) {
+	a := newTestAtsumaru("")
+	a.info = &atsumaruMangaInfo{Title: "Solo Leveling"}
+	a.page = &atsumaruMangaPage{}
+	payload := `{"mangaPage": {
+		"title": "Solo Leveling",
+		"otherNames": ["나 혼자만 레벨업", "Only I Level Up", "나 혼자만 레벨업"],
+		"synopsis": " Ten years ago... \n",
+		"authors": [{"name": "Chugong"}, {"name": "DUBU"}],
+		"status": "Completed"
+	}}`
+	if err := json.Unmarshal([]byte(payload), a.page); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := a.FetchMetadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Title != "Solo Leveling" || meta.Status != StatusCompleted || meta.Description != "Ten years ago..." || meta.Language != "en" {
+		t.Errorf("unexpected metadata %+v", meta)
+	}
+	// neither the title nor the repeated names are listed as alternate titles
+	if len(meta.AltTitles) != 2 || meta.AltTitles[0] != "나 혼자만 레벨업" {
+		t.Errorf("unexpected alternate titles %q", meta.AltTitles)
+	}
+	if len(meta.Authors) != 2 || meta.Authors[1] != "DUBU" {
+		t.Errorf("unexpected authors %q", meta.Authors)
+	}
+}
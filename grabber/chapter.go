@@ -10,12 +10,19 @@ type Chapter struct {
 	Title string
 	// Number is the chapter number
 	Number float64
+	// Volume is the volume the chapter belongs to, for sites that tell (empty
+	// otherwise)
+	Volume string
 	// PagesCount is the number of pages in the chapter
 	PagesCount int64
 	// Pages is the list of pages in the chapter
 	Pages []Page
 	// Language is the chapter language
 	Language string
+	// Scanlator is the scanlation group that released the chapter, for sites
+	// that host several groups' versions of the same chapters (empty if the
+	// site doesn't name them)
+	Scanlator string
 }
 
 // Page represents a chapter page
@@ -35,11 +42,21 @@ func (c Chapter) GetNumber() float64 {
 	return c.Number
 }
 
+// GetVolume returns the volume the chapter belongs to
+func (c Chapter) GetVolume() string {
+	return c.Volume
+}
+
 // GetLanguage returns the chapter language
 func (c Chapter) GetLanguage() string {
 	return c.Language
 }
 
+// GetScanlator returns the chapter scanlation group
+func (c Chapter) GetScanlator() string {
+	return c.Scanlator
+}
+
 // GetTitle returns the chapter title removing whitespace and newlines
 func (c Chapter) GetTitle() string {
 	title := strings.TrimSpace(c.Title)
@@ -18,10 +18,16 @@ type Titleable interface {
 	GetTitle() string
 }
 
+// Volumeable represents an object that can belong to a volume
+type Volumeable interface {
+	GetVolume() string
+}
+
 // Filterable represents an filterable objects
 type Filterable interface {
 	Enumerable
 	Titleable
+	Volumeable
 }
 
 // Filterables represents a slice of Filterable
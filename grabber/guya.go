@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/elboletaire/manga-downloader/http"
 )
@@ -59,6 +60,27 @@ func (g *Guya) FetchTitle() (string, error) {
 	return g.title, nil
 }
 
+// FetchMetadata returns the series' details. The api tells no status: these
+// instances host a handful of series, whether finished or not.
+func (g *Guya) FetchMetadata() (*SeriesMetadata, error) {
+	feed, err := g.seriesData()
+	if err != nil {
+		return nil, err
+	}
+	title, err := g.FetchTitle()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SeriesMetadata{
+		Title:       title,
+		Authors:     appendUnique(nil, feed.Author, feed.Artist),
+		Description: strings.TrimSpace(feed.Description),
+		Language:    "en",
+		URL:         g.URL,
+	}, nil
+}
+
 // FetchChapters returns the chapters of the manga
 func (g Guya) FetchChapters() (Filterables, []error) {
 	feed, err := g.seriesData()
@@ -180,6 +202,9 @@ func preferredGroup(groups map[string][]string, preferredSort []string) string {
 type guyaSeriesFeed struct {
 	Slug          string   `json:"slug"`
 	Title         string   `json:"title"`
+	Description   string   `json:"description"`
+	Author        string   `json:"author"`
+	Artist        string   `json:"artist"`
 	PreferredSort []string `json:"preferred_sort"`
 	Chapters      map[string]struct {
 		Title  string              `json:"title"`
@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"path"
 	"regexp"
+	"slices"
 	"strconv"
 	"time"
 
@@ -18,6 +19,9 @@ import (
 type Mangadex struct {
 	*Grabber
 	title string
+	// manga caches the manga object, shared by FetchTitle, FetchCover and
+	// FetchMetadata
+	manga *mangadexManga
 	// rateLimiter rate limiter for the FetchChapter method. This call uses the '/at-home' endpoint which has a rate limit
 	// of 40 calls per minute, if we exceed this limit we get a 429, and the consequent chapters fail. This may eventually
 	// lead to an IP ban.
@@ -48,22 +52,10 @@ func (m *Mangadex) FetchTitle() (string, error) {
 		return m.title, nil
 	}
 
-	id := getUuid(m.URL)
-
-	rbody, err := http.Get(http.RequestParams{
-		URL:     "https://api.mangadex.org/manga/" + id,
-		Referer: m.BaseUrl(),
-	})
+	body, err := m.fetchManga()
 	if err != nil {
 		return "", err
 	}
-	defer rbody.Close()
-
-	// decode json response
-	body := mangadexManga{}
-	if err = json.NewDecoder(rbody).Decode(&body); err != nil {
-		return "", err
-	}
 
 	// fetch the title in the requested language
 	if m.Settings.Language != "" {
@@ -88,6 +80,103 @@ func (m *Mangadex) FetchTitle() (string, error) {
 	return m.title, nil
 }
 
+// FetchCover returns the URL of the manga's main cover
+func (m *Mangadex) FetchCover() (string, error) {
+	body, err := m.fetchManga()
+	if err != nil {
+		return "", err
+	}
+
+	for _, rel := range body.Data.Relationships {
+		if rel.Type == "cover_art" && rel.Attributes.FileName != "" {
+			return fmt.Sprintf("https://uploads.mangadex.org/covers/%s/%s", body.Data.Id, rel.Attributes.FileName), nil
+		}
+	}
+
+	return "", nil
+}
+
+// FetchMetadata returns the manga's details
+func (m *Mangadex) FetchMetadata() (*SeriesMetadata, error) {
+	body, err := m.fetchManga()
+	if err != nil {
+		return nil, err
+	}
+	title, err := m.FetchTitle()
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := body.Data.Attributes
+	meta := &SeriesMetadata{
+		Title:    title,
+		Status:   attrs.Status,
+		Year:     attrs.Year,
+		Language: m.Settings.Language,
+		URL:      m.URL,
+	}
+	// the main title (but the one picked as Title) first, every translation next
+	for _, t := range attrs.Title {
+		meta.AltTitles = appendUnique(meta.AltTitles, t)
+	}
+	for _, alt := range attrs.AltTitles {
+		for _, t := range alt {
+			meta.AltTitles = appendUnique(meta.AltTitles, t)
+		}
+	}
+	meta.AltTitles = slices.DeleteFunc(meta.AltTitles, func(t string) bool { return t == title })
+
+	for _, rel := range body.Data.Relationships {
+		if rel.Type == "author" || rel.Type == "artist" {
+			meta.Authors = appendUnique(meta.Authors, rel.Attributes.Name)
+		}
+	}
+
+	for _, lang := range []string{m.Settings.Language, "en"} {
+		if desc, ok := attrs.Description[lang]; ok && desc != "" {
+			meta.Description = desc
+			break
+		}
+	}
+
+	return meta, nil
+}
+
+// fetchManga fetches (and caches) the manga object, along with its cover and
+// its authors
+func (m *Mangadex) fetchManga() (*mangadexManga, error) {
+	if m.manga != nil {
+		return m.manga, nil
+	}
+
+	id := getUuid(m.URL)
+	params := url.Values{}
+	params.Add("includes[]", "cover_art")
+	params.Add("includes[]", "author")
+	params.Add("includes[]", "artist")
+
+	rbody, err := http.Get(http.RequestParams{
+		URL:     "https://api.mangadex.org/manga/" + id + "?" + params.Encode(),
+		Referer: m.BaseUrl(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rbody.Close()
+
+	// decode json response
+	body := &mangadexManga{}
+	if err = json.NewDecoder(rbody).Decode(body); err != nil {
+		return nil, err
+	}
+	if body.Data.Id == "" {
+		body.Data.Id = id
+	}
+	m.manga = body
+
+	return body, nil
+}
+
 // FetchChapters returns the chapters of the manga
 func (m Mangadex) FetchChapters() (chapters Filterables, errs []error) {
 	id := getUuid(m.URL)
@@ -132,6 +221,7 @@ func (m Mangadex) FetchChapters() (chapters Filterables, errs []error) {
 			chapters = append(chapters, &MangadexChapter{
 				Chapter{
 					Number:     num,
+					Volume:     c.Attributes.Volume,
 					Title:      c.Attributes.Title,
 					Language:   c.Attributes.TranslatedLanguage,
 					PagesCount: c.Attributes.Pages,
@@ -172,6 +262,7 @@ func (m Mangadex) FetchChapter(f Filterable) (*Chapter, error) {
 	chapter := &Chapter{
 		Title:      fmt.Sprintf("Chapter %04d %s", int64(f.GetNumber()), chap.Title),
 		Number:     f.GetNumber(),
+		Volume:     f.GetVolume(),
 		PagesCount: int64(pcount),
 		Language:   chap.Language,
 	}
@@ -192,9 +283,25 @@ func (m Mangadex) FetchChapter(f Filterable) (*Chapter, error) {
 type mangadexManga struct {
 	Id   string
 	Data struct {
+		Id         string
 		Attributes struct {
-			Title     map[string]string
-			AltTitles altTitles
+			Title       map[string]string
+			AltTitles   altTitles
+			Description map[string]string
+			// Status is "ongoing", "completed", "hiatus" or "cancelled"
+			Status string
+			Year   int
+		}
+		// Relationships are the manga's related objects, the ones asked for
+		// with includes[] carrying their attributes (e.g. the cover_art's)
+		Relationships []struct {
+			Type       string
+			Attributes struct {
+				// FileName is the cover_art's
+				FileName string
+				// Name is the author's or artist's
+				Name string
+			}
 		}
 	}
 }
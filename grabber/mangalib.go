@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"net/url"
 	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/elboletaire/manga-downloader/http"
 )
@@ -30,6 +32,8 @@ const mangalibImageServer = "https://img2.imglib.info"
 type Mangalib struct {
 	*Grabber
 	title string
+	// manga caches the manga feed, shared by FetchTitle and FetchCover
+	manga *mangalibMangaFeed
 }
 
 func NewMangalib(g *Grabber) *Mangalib {
@@ -39,12 +43,12 @@ func NewMangalib(g *Grabber) *Mangalib {
 // MangalibChapter represents a Mangalib Chapter
 type MangalibChapter struct {
 	Chapter
-	// Volume and Num are the raw "volume"/"number" fields as returned by the
-	// chapters list api, needed verbatim to requery the single chapter (the
-	// parsed Number float64 isn't precise/safe enough to rebuild them, e.g.
-	// leading zeros or non-numeric suffixes some series use for extras).
-	Volume string
-	Num    string
+	// Num is the raw "number" field as returned by the chapters list api,
+	// needed verbatim to requery the single chapter (the parsed Number float64
+	// isn't precise/safe enough to rebuild it, e.g. leading zeros or
+	// non-numeric suffixes some series use for extras). The raw "volume" is
+	// needed too, and kept verbatim as the chapter's Volume.
+	Num string
 }
 
 // Test returns true if the URL is a mangalib.me URL
@@ -59,36 +63,87 @@ func (m *Mangalib) FetchTitle() (string, error) {
 		return m.title, nil
 	}
 
-	slug, err := m.seriesSlug()
+	feed, err := m.fetchManga()
 	if err != nil {
 		return "", err
 	}
 
-	body, err := http.GetText(http.RequestParams{
-		URL:     mangalibApi + "/manga/" + slug,
-		Referer: m.BaseUrl(),
-	})
+	title := feed.Data.RusName
+	if title == "" {
+		title = feed.Data.Name
+	}
+	m.title = sanitizeTitle(title)
+
+	return m.title, nil
+}
+
+// FetchCover returns the URL of the manga's cover
+func (m *Mangalib) FetchCover() (string, error) {
+	feed, err := m.fetchManga()
 	if err != nil {
 		return "", err
 	}
 
-	feed := struct {
-		Data struct {
-			Name    string `json:"name"`
-			RusName string `json:"rus_name"`
-		} `json:"data"`
-	}{}
-	if err = json.Unmarshal([]byte(body), &feed); err != nil {
-		return "", err
+	return feed.Data.Cover.Default, nil
+}
+
+// FetchMetadata returns the manga's details
+func (m *Mangalib) FetchMetadata() (*SeriesMetadata, error) {
+	feed, err := m.fetchManga()
+	if err != nil {
+		return nil, err
+	}
+	title, err := m.FetchTitle()
+	if err != nil {
+		return nil, err
 	}
 
-	title := feed.Data.RusName
-	if title == "" {
-		title = feed.Data.Name
+	data := feed.Data
+	meta := &SeriesMetadata{
+		Title:       title,
+		AltTitles:   appendUnique(nil, append([]string{data.Name, data.EngName}, data.OtherNames...)...),
+		Description: strings.TrimSpace(data.Summary),
+		Status:      mangalibStatuses[data.Status.Id],
+		Language:    "ru",
+		URL:         m.URL,
 	}
-	m.title = sanitizeTitle(title)
+	meta.AltTitles = slices.DeleteFunc(meta.AltTitles, func(t string) bool { return t == title })
+	for _, author := range append(data.Authors, data.Artists...) {
+		meta.Authors = appendUnique(meta.Authors, author.Name)
+	}
+	// releaseDate is the year alone, as a string
+	meta.Year, _ = strconv.Atoi(data.ReleaseDate)
 
-	return m.title, nil
+	return meta, nil
+}
+
+// fetchManga fetches (and caches) the manga feed, along with the fields
+// FetchMetadata needs (the api leaves them out unless asked for)
+func (m *Mangalib) fetchManga() (*mangalibMangaFeed, error) {
+	if m.manga != nil {
+		return m.manga, nil
+	}
+
+	slug, err := m.seriesSlug()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := http.GetText(http.RequestParams{
+		URL:     mangalibApi + "/manga/" + slug + "?fields[]=summary&fields[]=otherNames&fields[]=authors&fields[]=artists&fields[]=releaseDate",
+		Referer: m.BaseUrl(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &mangalibMangaFeed{}
+	if err = json.Unmarshal([]byte(body), feed); err != nil {
+		return nil, err
+	}
+	m.manga = feed
+
+	return feed, nil
 }
 
 // FetchChapters returns the chapters of the manga
@@ -120,10 +175,10 @@ func (m Mangalib) FetchChapters() (chapters Filterables, errs []error) {
 		chapters = append(chapters, &MangalibChapter{
 			Chapter{
 				Number:   num,
+				Volume:   c.Volume,
 				Title:    c.Name,
 				Language: "ru",
 			},
-			c.Volume,
 			c.Number,
 		})
 	}
@@ -170,6 +225,7 @@ func (m Mangalib) FetchChapter(f Filterable) (*Chapter, error) {
 	chapter := &Chapter{
 		Title:      f.GetTitle(),
 		Number:     f.GetNumber(),
+		Volume:     f.GetVolume(),
 		Language:   "ru",
 		PagesCount: int64(len(feed.Data.Pages)),
 	}
@@ -200,6 +256,41 @@ func (m Mangalib) seriesSlug() (string, error) {
 	return matches[1], nil
 }
 
+// mangalibMangaFeed is the JSON feed for the manga itself
+type mangalibMangaFeed struct {
+	Data struct {
+		Name       string   `json:"name"`
+		RusName    string   `json:"rus_name"`
+		EngName    string   `json:"eng_name"`
+		OtherNames []string `json:"otherNames"`
+		Summary    string   `json:"summary"`
+		Authors    []struct {
+			Name string `json:"name"`
+		} `json:"authors"`
+		Artists []struct {
+			Name string `json:"name"`
+		} `json:"artists"`
+		ReleaseDate string `json:"releaseDate"`
+		Status      struct {
+			// Id is a key of mangalibStatuses
+			Id int `json:"id"`
+		} `json:"status"`
+		Cover struct {
+			// Default is the full size cover's URL
+			Default string `json:"default"`
+		} `json:"cover"`
+	} `json:"data"`
+}
+
+// mangalibStatuses maps the manga status ids to SeriesMetadata statuses. 3
+// (announced) has no chapters out yet, so it's left out.
+var mangalibStatuses = map[int]string{
+	1: StatusOngoing,
+	2: StatusCompleted,
+	4: StatusHiatus,
+	5: StatusCancelled,
+}
+
 // mangalibChaptersFeed is the JSON feed for the chapters list. Each entry is
 // one unique (volume, number) chapter - translation-team alternatives are
 // nested under "branches" and not exposed here, since the plain chapter
@@ -33,6 +33,8 @@ type SiteSelector struct {
 	Chapter      string
 	ChapterTitle string
 	Image        string
+	// Cover is the series page's cover image (see FetchCover)
+	Cover string
 }
 
 // PlainHTMLChapter represents a PlainHTML Chapter
@@ -183,6 +185,7 @@ func (m *PlainHTML) Test() (bool, error) {
 			ChapterTitle: ".chapternum",
 			Link:         "a",
 			Image:        "#readerarea img",
+			Cover:        ".thumb img",
 		},
 		// hivetoons.org (VoidScans/HiveToons): the series page also embeds a
 		// "recently added" widget and a "continue reading" link that reuse
@@ -248,6 +251,7 @@ func (m *PlainHTML) Test() (bool, error) {
 			ChapterTitle: ".chapternum",
 			Link:         "a.chapter-link-overlay",
 			Image:        "#readerarea img",
+			Cover:        ".thumb img",
 		},
 		// asmotoon.com (Asmodeus Scans): chapter rows are plain <a> children
 		// of #chapters. The reader lazy-loads pages, so most <img> keep a
@@ -344,6 +348,32 @@ func (m PlainHTML) FetchTitle() (string, error) {
 	return sanitizeTitle(textWithoutNoise(m.doc.Find(m.site.Title))), nil
 }
 
+// FetchCover returns the URL of the series' cover: the image matching the
+// site's Cover selector or, for the sites without one, the page's og:image,
+// which most manga themes set to the series thumbnail
+func (m PlainHTML) FetchCover() (string, error) {
+	src := ""
+	if m.site.Cover != "" {
+		img := m.doc.Find(m.site.Cover).First()
+		// lazy-loading themes leave a placeholder in src
+		for _, attr := range []string{"data-src", "data-lazy-src", "src"} {
+			if v, ok := img.Attr(attr); ok && strings.TrimSpace(v) != "" {
+				src = strings.TrimSpace(v)
+				break
+			}
+		}
+	}
+	if src == "" {
+		src, _ = m.doc.Find(`meta[property="og:image"]`).Attr("content")
+		src = strings.TrimSpace(src)
+	}
+	if src == "" {
+		return "", nil
+	}
+
+	return m.resolveURL(src), nil
+}
+
 // textWithoutNoise returns a selection's text with common non-title noise
 // stripped out first: <small> blocks (mangahub.io nests both a huge list of
 // alternate-script/language titles in its <h1> — long enough to blow past
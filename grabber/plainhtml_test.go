@@ -296,6 +296,32 @@ func TestFetchTitleStripsNoise(t *testing.T) {
 	}
 }
 
+func TestFetchCover(t *testing.T) {
+	html := `<html><head><meta property="og:image" content="/og.jpg"></head><body>
+		<div class="thumb"><img src="data:image/gif;base64," data-src="/uploads/cover.webp"></div>
+	</body></html>`
+	m := PlainHTML{
+		Grabber: &Grabber{URL: "https://example.com/manga/series/"},
+		doc:     docFromHTML(t, html),
+		site:    SiteSelector{Cover: ".thumb img"},
+	}
+
+	if got, _ := m.FetchCover(); got != "https://example.com/uploads/cover.webp" {
+		t.Errorf("FetchCover() = %q, want the lazy-loaded thumbnail", got)
+	}
+
+	// sites without a Cover selector fall back to og:image
+	m.site.Cover = ""
+	if got, _ := m.FetchCover(); got != "https://example.com/og.jpg" {
+		t.Errorf("FetchCover() = %q, want the og:image", got)
+	}
+
+	m.doc = docFromHTML(t, "<html><body></body></html>")
+	if got, err := m.FetchCover(); got != "" || err != nil {
+		t.Errorf("FetchCover() = %q, %v; want no cover", got, err)
+	}
+}
+
 func TestSanitizeTitle(t *testing.T) {
 	cases := []struct {
 		in   string
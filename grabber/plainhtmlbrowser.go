@@ -46,6 +46,7 @@ var browserSelectors = []BrowserSiteSelector{
 			ChapterTitle: "a",
 			Link:         "a",
 			Image:        "div.reading-content img",
+			Cover:        ".summary_image img",
 		},
 		Domains:      []string{"toongod.org", "dragontea.ink", "manhuaus.com", "toonily.com"},
 		ChaptersWait: "li.wp-manga-chapter",
@@ -64,6 +65,7 @@ var browserSelectors = []BrowserSiteSelector{
 			ChapterTitle: "a",
 			Link:         "a",
 			Image:        "div.reading-content img",
+			Cover:        ".summary_image img",
 		},
 		Domains:      []string{"setsuscans.com"},
 		ChaptersWait: "li.wp-manga-chapter",
@@ -98,6 +100,7 @@ var browserSelectors = []BrowserSiteSelector{
 			ChapterTitle: ".chapternum",
 			Link:         "a",
 			Image:        "#readerarea img",
+			Cover:        ".thumb img",
 		},
 		Domains:      []string{"sushiscan.net", "drakecomic.org"},
 		ChaptersWait: "#chapterlist li",
@@ -158,6 +161,7 @@ var browserSelectors = []BrowserSiteSelector{
 			ChapterTitle: "a",
 			Link:         "a",
 			Image:        `div.reading-content img[id^="image-"]`,
+			Cover:        ".summary_image img",
 		},
 		Domains:      []string{"manhuatop.org"},
 		ChaptersWait: "li.wp-manga-chapter",
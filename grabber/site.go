@@ -6,6 +6,7 @@ import (
 	"errors"
 	"net/url"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -25,6 +26,15 @@ type Grabber struct {
 type Settings struct {
 	// Bundle is a flag to indicate if the chapters should be bundled into a single file
 	Bundle bool
+	// BundleBy is how the bundled chapters are split into files ("range" for
+	// a single file, or "volume" for a file per volume)
+	BundleBy string
+	// BundleMaxPages is the max pages of a bundle's file, a bigger bundle
+	// being split into several (0 for no limit)
+	BundleMaxPages int
+	// BundleMaxSize is the max size of a bundle's file, e.g. "700MB", a
+	// bigger bundle being split into several (empty for no limit)
+	BundleMaxSize string
 	// MaxConcurrency determines max download concurrency
 	MaxConcurrency MaxConcurrency
 	// Language is the preferred language for downloading chapters
@@ -34,8 +44,11 @@ type Settings struct {
 	Scanlator string
 	// FilenameTemplate is the template for the filename
 	FilenameTemplate string
-	// Format is the desired output format ("cbz" or "raw")
+	// Format is the desired output format ("cbz", "epub", "pdf" or "raw")
 	Format string
+	// ReadingDirection is the reading direction of the formats storing one
+	// ("rtl" for manga, or "ltr")
+	ReadingDirection string
 	// ConvertImages is the comma separated list of source image formats to
 	// transcode to JPEG when packing ("avif", "avif,webp" or "none"), so pages
 	// served in formats e-readers can't display end up readable
@@ -49,6 +62,21 @@ type Settings struct {
 	BrowserVisible bool
 	// Retry is the number of retries for failed page downloads
 	Retry uint8
+	// OnExists is what to do when an output file already exists ("version",
+	// "skip", "overwrite" or "fail")
+	OnExists string
+	// Force downloads chapters again even if the output directory's ledger
+	// says they were already saved
+	Force bool
+	// Upgrade downloads again the saved chapters the site changed since
+	// (re-uploaded with other pages), replacing their files
+	Upgrade bool
+	// UpgradeBackup keeps the files replaced by Upgrade as a backup
+	UpgradeBackup bool
+	// Cover is where the series cover goes, for the sites telling it ("none",
+	// "bundle" for the first page of bundles, "file" for cover.jpg and
+	// folder.jpg files next to them, or "both")
+	Cover string
 }
 
 // MaxConcurrency is the max concurrency for a site
@@ -73,13 +101,19 @@ type Site interface {
 	FetchTitle() (string, error)
 	// BaseUrl returns the base url of the site
 	BaseUrl() string
+	// GetURL returns the url of the series
+	GetURL() string
 	// GetFilenameTemplate returns the filename template
 	GetFilenameTemplate() string
-	// GetFormat returns the desired output format ("cbz" or "raw")
+	// GetFormat returns the desired output format ("cbz", "epub", "pdf" or "raw")
 	GetFormat() string
+	// GetReadingDirection returns the reading direction ("rtl" or "ltr")
+	GetReadingDirection() string
 	// GetConvertImages returns the set of source image formats to transcode to
 	// JPEG when packing
 	GetConvertImages() ConvertFormats
+	// GetOnExists returns what to do when an output file already exists
+	GetOnExists() string
 	// GetMaxConcurrency returns the max concurrency for the site
 	GetMaxConcurrency() MaxConcurrency
 	// GetPreferredLanguage returns the preferred language for the site
@@ -90,6 +124,60 @@ type Site interface {
 	GetRetries() uint8
 }
 
+// Coverer is implemented by the sites telling the series' cover image
+type Coverer interface {
+	// FetchCover returns the URL of the series' cover image, empty when the
+	// series has none
+	FetchCover() (string, error)
+}
+
+// Series statuses, as told by SeriesMetadata.Status
+const (
+	StatusOngoing   = "ongoing"
+	StatusCompleted = "completed"
+	StatusHiatus    = "hiatus"
+	StatusCancelled = "cancelled"
+)
+
+// SeriesMetadata is what a site tells about a series as a whole, rather than
+// about its chapters. Anything the site doesn't tell is left empty.
+type SeriesMetadata struct {
+	Title string
+	// AltTitles are the series' other titles (translations, romanizations...)
+	AltTitles []string
+	// Authors are the series' authors and artists, each named once
+	Authors     []string
+	Description string
+	// Status is one of StatusOngoing, StatusCompleted, StatusHiatus or
+	// StatusCancelled
+	Status string
+	// Year is the year the series started
+	Year int
+	// Language is the language the chapters are downloaded in
+	Language string
+	// URL is the series' page on the site
+	URL string
+}
+
+// MetadataFetcher is implemented by the sites whose APIs tell the series'
+// details (see SeriesMetadata)
+type MetadataFetcher interface {
+	// FetchMetadata returns the series' details
+	FetchMetadata() (*SeriesMetadata, error)
+}
+
+// appendUnique appends the non-empty values not already in list
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v != "" && !slices.Contains(list, v) {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
+
 // IdentifySite returns the site passing the Test() for the specified url
 func (g *Grabber) IdentifySite() (Site, []error) {
 	sites := []Site{
@@ -162,6 +250,11 @@ func (g Grabber) BaseUrl() string {
 	return u.Scheme + "://" + u.Host
 }
 
+// GetURL returns the url of the series
+func (g Grabber) GetURL() string {
+	return g.URL
+}
+
 // GetPreferredLanguage returns the preferred language for the site
 func (g Grabber) GetPreferredLanguage() string {
 	return g.Settings.Language
@@ -192,11 +285,21 @@ func (g Grabber) GetRetries() uint8 {
 	return g.Settings.Retry
 }
 
-// GetFormat returns the desired output format ("cbz" or "raw")
+// GetFormat returns the desired output format ("cbz", "epub", "pdf" or "raw")
 func (g Grabber) GetFormat() string {
 	return g.Settings.Format
 }
 
+// GetReadingDirection returns the reading direction ("rtl" or "ltr")
+func (g Grabber) GetReadingDirection() string {
+	return g.Settings.ReadingDirection
+}
+
+// GetOnExists returns what to do when an output file already exists
+func (g Grabber) GetOnExists() string {
+	return g.Settings.OnExists
+}
+
 // GetConvertImages returns the set of source image formats to transcode to
 // JPEG when packing. The value is validated at startup, so a parse error here
 // can only mean it never went through the command flags; an empty set simply
@@ -217,7 +320,10 @@ func (g *Grabber) InitFlags(cmd *cobra.Command) {
 	g.Settings.FilenameTemplate = cmd.Flag("filename-template").Value.String()
 	g.Settings.Retry = maxUint8Flag(cmd.Flag("retry"), 3)
 	g.Settings.Format = cmd.Flag("format").Value.String()
+	g.Settings.ReadingDirection = cmd.Flag("reading-direction").Value.String()
 	g.Settings.ConvertImages = cmd.Flag("convert-images").Value.String()
+	g.Settings.OnExists = cmd.Flag("on-exists").Value.String()
+	g.Settings.Cover = cmd.Flag("cover").Value.String()
 }
 
 // NewSite returns a new site based on the passed url
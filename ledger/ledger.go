@@ -0,0 +1,366 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+// Package ledger keeps a persistent record of the chapters already saved to
+// an output directory, so re-running the same download skips what's already
+// there instead of piling up " v2", " v3" copies of every chapter.
+//
+// The ledger lives in the output directory itself (see Filename), next to the
+// files it describes: moving or syncing a library folder keeps its history,
+// and two libraries never share (or clobber) each other's records.
+package ledger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/elboletaire/manga-downloader/grabber"
+)
+
+// Filename is the name of the ledger file inside an output directory
+const Filename = ".manga-downloader.json"
+
+// Entry is a single saved chapter
+type Entry struct {
+	// Number is the chapter number
+	Number float64 `json:"number"`
+	// Language is the chapter language (empty if the site doesn't report one)
+	Language string `json:"language,omitempty"`
+	// Scanlator is the scanlation group (empty if the site doesn't name it)
+	Scanlator string `json:"scanlator,omitempty"`
+	// Pages is the number of pages saved
+	Pages int64 `json:"pages"`
+	// SourcePages is the number of pages the chapter was downloaded from,
+	// which the page transforms can slice into more pages saved, or restitch
+	// into fewer; 0 for imported chapters
+	SourcePages int64 `json:"source_pages,omitempty"`
+	// Filename is the saved file (or folder, for raw), relative to the output
+	// directory. Bundled chapters all share the bundle's filename.
+	Filename string `json:"filename"`
+	// Hash is the sha256 of the saved file contents (see HashPath)
+	Hash string `json:"hash"`
+	// PagesHash identifies the pages the chapter was downloaded from (see
+	// PagesHash), empty for imported chapters
+	PagesHash string `json:"pages_hash,omitempty"`
+}
+
+// Series is the record of a single series, keyed by its URL in the ledger
+type Series struct {
+	// Title is the series title, as reported by the site
+	Title string `json:"title"`
+	// Chapters are the saved chapters, in the order they were saved
+	Chapters []Entry `json:"chapters"`
+}
+
+// Ledger is the record of every chapter saved to an output directory. It's
+// safe for concurrent use: chapters are recorded from their download
+// goroutines as soon as each one is saved.
+type Ledger struct {
+	mu  sync.Mutex
+	dir string
+	// Series are the recorded series, keyed by their URL
+	Series map[string]*Series `json:"series"`
+}
+
+// Open loads the ledger of the given output directory. A directory without a
+// ledger yet returns an empty one, written on the first Record.
+func Open(dir string) (*Ledger, error) {
+	l := &Ledger{
+		dir:    dir,
+		Series: map[string]*Series{},
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, Filename))
+	if errors.Is(err, fs.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, l); err != nil {
+		return nil, err
+	}
+	if l.Series == nil {
+		l.Series = map[string]*Series{}
+	}
+
+	return l, nil
+}
+
+// Record adds saved chapters to the given series and writes the ledger to
+// disk straight away, so an interrupted run keeps track of everything saved
+// up to that point.
+func (l *Ledger) Record(url, title string, entries ...Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	series := l.Series[url]
+	if series == nil {
+		series = &Series{}
+		l.Series[url] = series
+	}
+	series.Title = title
+	series.Chapters = append(series.Chapters, entries...)
+
+	return l.save()
+}
+
+// RecordFile records the chapters saved into filename (a single one, or every
+// chapter of a bundle), hashing the file once for all of them
+func (l *Ledger) RecordFile(url, title, filename string, chapters ...*grabber.Chapter) error {
+	hash, err := HashPath(filepath.Join(l.dir, filename))
+	if err != nil {
+		return err
+	}
+
+	entries := make([]Entry, len(chapters))
+	for i, chapter := range chapters {
+		entries[i] = Entry{
+			Number:      chapter.GetNumber(),
+			Language:    chapter.Language,
+			Scanlator:   chapter.Scanlator,
+			Pages:       chapter.PagesCount,
+			SourcePages: chapter.PagesCount,
+			Filename:    filename,
+			Hash:        hash,
+			PagesHash:   PagesHash(chapter),
+		}
+	}
+
+	return l.Record(url, title, entries...)
+}
+
+// Forget removes an entry from the given series, once the chapter it records
+// has been replaced (see Outdated)
+func (l *Ledger) Forget(url string, entry Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	series := l.Series[url]
+	if series == nil {
+		return nil
+	}
+	for i, e := range series.Chapters {
+		if e == entry {
+			series.Chapters = append(series.Chapters[:i], series.Chapters[i+1:]...)
+			return l.save()
+		}
+	}
+
+	return nil
+}
+
+// Only reports whether url is the only series recorded, that is, whether the
+// output directory is the series' own folder
+func (l *Ledger) Only(url string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.Series[url]
+
+	return ok && len(l.Series) == 1
+}
+
+// Find returns the entry recording the given chapter, if any (the first one,
+// when several do)
+func (l *Ledger) Find(url string, chap grabber.Filterable) (Entry, bool) {
+	for _, e := range l.Entries(url) {
+		if e.Matches(chap) {
+			return e, true
+		}
+	}
+
+	return Entry{}, false
+}
+
+// Highest returns the highest chapter number saved for the given series, and
+// false if none is
+func (l *Ledger) Highest(url string) (float64, bool) {
+	entries := l.Entries(url)
+	if len(entries) == 0 {
+		return 0, false
+	}
+
+	highest := entries[0].Number
+	for _, e := range entries[1:] {
+		highest = max(highest, e.Number)
+	}
+
+	return highest, true
+}
+
+// Entries returns the chapters recorded for the given series whose files are
+// still on disk: a file that was deleted (or moved away) since is no longer
+// "already saved", whatever the ledger says.
+func (l *Ledger) Entries(url string) []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	series := l.Series[url]
+	if series == nil {
+		return nil
+	}
+
+	entries := []Entry{}
+	for _, e := range series.Chapters {
+		if _, err := os.Stat(filepath.Join(l.dir, e.Filename)); err == nil {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries
+}
+
+// Pending splits the passed chapters into the ones still to be downloaded
+// and the ones already saved for the given series.
+//
+// A chapter is saved when an entry shares its number, language and
+// scanlator. Language and scanlator only count when both sides know them:
+// most sites report neither while listing chapters, only once a chapter is
+// fetched. Each entry accounts for a single chapter, so a site listing the
+// same number twice (e.g. one entry per translation) still downloads the
+// second one when only the first was saved.
+func (l *Ledger) Pending(url string, chapters grabber.Filterables) (pending, saved grabber.Filterables) {
+	entries := l.Entries(url)
+	used := make([]bool, len(entries))
+
+	pending, saved = grabber.Filterables{}, grabber.Filterables{}
+	for _, chap := range chapters {
+		found := false
+		for i, e := range entries {
+			if !used[i] && e.Matches(chap) {
+				used[i], found = true, true
+				break
+			}
+		}
+		if found {
+			saved = append(saved, chap)
+		} else {
+			pending = append(pending, chap)
+		}
+	}
+
+	return
+}
+
+// Matches reports whether the entry records the given chapter
+func (e Entry) Matches(chap grabber.Filterable) bool {
+	if e.Number != chap.GetNumber() {
+		return false
+	}
+	// not part of Filterable: only the sites that list one entry per language
+	// (or per group) have one to report before the chapter is fetched
+	if l, ok := chap.(interface{ GetLanguage() string }); ok && !sameOrUnknown(e.Language, l.GetLanguage()) {
+		return false
+	}
+	if s, ok := chap.(interface{ GetScanlator() string }); ok && !sameOrUnknown(e.Scanlator, s.GetScanlator()) {
+		return false
+	}
+
+	return true
+}
+
+// Outdated reports whether the site changed the chapter since the entry was
+// recorded: a scanlator re-uploading it with fixed or better pages. That's
+// told, for chapters downloaded (not imported), by the number of pages they
+// were downloaded from and by the pages themselves. The pages saved can't
+// tell it: the page transforms change their number.
+func (e Entry) Outdated(chapter *grabber.Chapter) bool {
+	if e.SourcePages != 0 && e.SourcePages != chapter.PagesCount {
+		return true
+	}
+
+	return e.PagesHash != "" && e.PagesHash != PagesHash(chapter)
+}
+
+// PagesHash returns the hex sha256 of a chapter's page URLs, empty when the
+// chapter has none. Query strings and fragments are left out: many sites sign
+// their image URLs with tokens that change on every visit.
+func PagesHash(chapter *grabber.Chapter) string {
+	if len(chapter.Pages) == 0 {
+		return ""
+	}
+
+	h := sha256.New()
+	for _, page := range chapter.Pages {
+		u, _, _ := strings.Cut(page.URL, "?")
+		u, _, _ = strings.Cut(u, "#")
+		io.WriteString(h, u+"\n")
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// sameOrUnknown reports whether a and b are equal, or either is unknown
+func sameOrUnknown(a, b string) bool {
+	return a == "" || b == "" || a == b
+}
+
+// save writes the ledger to disk through a temporary file, so a crash while
+// writing never leaves a truncated ledger behind. Callers must hold mu.
+func (l *Ledger) save() error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(l.dir, Filename+".*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(l.dir, Filename))
+}
+
+// HashPath returns the hex sha256 of a saved file. Raw chapters are saved as
+// folders, so for a directory the hash covers every file in it, walked in
+// lexical order so the result doesn't depend on the filesystem.
+func HashPath(path string) (string, error) {
+	h := sha256.New()
+
+	files := []string{}
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
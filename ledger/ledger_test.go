@@ -0,0 +1,263 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package ledger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elboletaire/manga-downloader/grabber"
+)
+
+const testURL = "https://example.com/manga/test"
+
+// touch creates an empty file in dir, standing in for a saved chapter
+func touch(t *testing.T, dir, name string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+		t.Fatalf("creating %s: %s", name, err)
+	}
+}
+
+func TestOpenWithoutLedger(t *testing.T) {
+	l, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(l.Series) != 0 {
+		t.Errorf("expected an empty ledger, got %d series", len(l.Series))
+	}
+}
+
+func TestRecordPersists(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, dir, "Test 1.cbz")
+
+	l, _ := Open(dir)
+	if err := l.Record(testURL, "Test", Entry{Number: 1, Pages: 20, Filename: "Test 1.cbz", Hash: "abc"}); err != nil {
+		t.Fatalf("unexpected error recording: %s", err)
+	}
+
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatalf("unexpected error reopening: %s", err)
+	}
+	entries := reopened.Entries(testURL)
+	if len(entries) != 1 || entries[0].Pages != 20 || entries[0].Hash != "abc" {
+		t.Errorf("expected the recorded entry back, got %+v", entries)
+	}
+	if reopened.Series[testURL].Title != "Test" {
+		t.Errorf("expected the series title to be kept, got %q", reopened.Series[testURL].Title)
+	}
+}
+
+func TestRecordFileAndHighest(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, dir, "Test 1-2.cbz")
+
+	l, _ := Open(dir)
+	if _, ok := l.Highest(testURL); ok {
+		t.Error("expected no highest chapter for an unknown series")
+	}
+
+	err := l.RecordFile(testURL, "Test", "Test 1-2.cbz",
+		&grabber.Chapter{Number: 2, PagesCount: 10},
+		&grabber.Chapter{Number: 1.5, PagesCount: 5, Language: "en"},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error recording: %s", err)
+	}
+
+	entries := l.Entries(testURL)
+	if len(entries) != 2 || entries[0].Hash == "" || entries[0].Hash != entries[1].Hash {
+		t.Errorf("expected both chapters recorded with the bundle's hash, got %+v", entries)
+	}
+	if highest, ok := l.Highest(testURL); !ok || highest != 2 {
+		t.Errorf("expected 2 to be the highest chapter, got %g", highest)
+	}
+}
+
+func TestPendingSkipsSavedChapters(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, dir, "Test 1.cbz")
+
+	l, _ := Open(dir)
+	l.Record(testURL, "Test", Entry{Number: 1, Language: "en", Filename: "Test 1.cbz"})
+
+	chapters := grabber.Filterables{
+		&grabber.Chapter{Number: 1},
+		&grabber.Chapter{Number: 2},
+	}
+	pending, saved := l.Pending(testURL, chapters)
+	if len(saved) != 1 || saved[0].GetNumber() != 1 {
+		t.Errorf("expected chapter 1 to be saved, got %v", saved)
+	}
+	if len(pending) != 1 || pending[0].GetNumber() != 2 {
+		t.Errorf("expected chapter 2 to be pending, got %v", pending)
+	}
+
+	// another series in the same output dir is a different story
+	if pending, _ := l.Pending("https://example.com/manga/other", chapters); len(pending) != 2 {
+		t.Errorf("expected every chapter of another series to be pending, got %d", len(pending))
+	}
+}
+
+func TestPendingTellsLanguagesAndScanlatorsApart(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, dir, "Test 1.cbz")
+
+	l, _ := Open(dir)
+	l.Record(testURL, "Test", Entry{Number: 1, Language: "en", Scanlator: "Alpha", Filename: "Test 1.cbz"})
+
+	chapters := grabber.Filterables{
+		&grabber.Chapter{Number: 1, Language: "es"},
+		&grabber.Chapter{Number: 1, Language: "en", Scanlator: "Delta"},
+		&grabber.Chapter{Number: 1, Language: "en", Scanlator: "Alpha"},
+	}
+	pending, saved := l.Pending(testURL, chapters)
+	if len(saved) != 1 || saved[0] != chapters[2] {
+		t.Errorf("expected only the english Alpha release to be saved, got %v", saved)
+	}
+	if len(pending) != 2 {
+		t.Errorf("expected 2 pending chapters, got %d", len(pending))
+	}
+}
+
+// a site listing the same number twice (one entry per translation) must still
+// download the second one when only the first was saved
+func TestPendingCountsDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, dir, "Test 1.cbz")
+
+	l, _ := Open(dir)
+	l.Record(testURL, "Test", Entry{Number: 1, Filename: "Test 1.cbz"})
+
+	chapters := grabber.Filterables{
+		&grabber.Chapter{Number: 1, Title: "first"},
+		&grabber.Chapter{Number: 1, Title: "second"},
+	}
+	pending, saved := l.Pending(testURL, chapters)
+	if len(saved) != 1 || saved[0].GetTitle() != "first" {
+		t.Errorf("expected the first release to be saved, got %v", saved)
+	}
+	if len(pending) != 1 || pending[0].GetTitle() != "second" {
+		t.Errorf("expected the second release to be pending, got %v", pending)
+	}
+}
+
+func TestPendingIgnoresDeletedFiles(t *testing.T) {
+	l, _ := Open(t.TempDir())
+	l.Record(testURL, "Test", Entry{Number: 1, Filename: "Test 1.cbz"})
+
+	pending, _ := l.Pending(testURL, grabber.Filterables{&grabber.Chapter{Number: 1}})
+	if len(pending) != 1 {
+		t.Error("a chapter whose file is gone must be downloaded again")
+	}
+}
+
+func TestHashPath(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, dir, "file.cbz")
+
+	raw := filepath.Join(dir, "raw")
+	os.Mkdir(raw, 0755)
+	touch(t, raw, "000.jpg")
+	touch(t, raw, "001.jpg")
+
+	file, err := HashPath(filepath.Join(dir, "file.cbz"))
+	if err != nil {
+		t.Fatalf("unexpected error hashing a file: %s", err)
+	}
+	folder, err := HashPath(raw)
+	if err != nil {
+		t.Fatalf("unexpected error hashing a folder: %s", err)
+	}
+	if file == "" || folder == "" || file == folder {
+		t.Errorf("expected two distinct hashes, got %q and %q", file, folder)
+	}
+
+	again, _ := HashPath(raw)
+	if again != folder {
+		t.Errorf("hashing the same folder twice gave %q and %q", folder, again)
+	}
+}
+
+func TestOutdated(t *testing.T) {
+	pages := func(urls ...string) []grabber.Page {
+		p := []grabber.Page{}
+		for i, u := range urls {
+			p = append(p, grabber.Page{Number: int64(i + 1), URL: u})
+		}
+		return p
+	}
+	saved := &grabber.Chapter{Number: 1, PagesCount: 2, Pages: pages("https://cdn/1.jpg?token=a", "https://cdn/2.jpg")}
+	entry := Entry{Number: 1, Pages: 2, SourcePages: 2, PagesHash: PagesHash(saved)}
+
+	cases := []struct {
+		name    string
+		entry   Entry
+		chapter *grabber.Chapter
+		want    bool
+	}{
+		{"unchanged", entry, saved, false},
+		{"new signing token", entry, &grabber.Chapter{Number: 1, PagesCount: 2, Pages: pages("https://cdn/1.jpg?token=b", "https://cdn/2.jpg")}, false},
+		{"page added", entry, &grabber.Chapter{Number: 1, PagesCount: 3, Pages: pages("https://cdn/1.jpg", "https://cdn/2.jpg", "https://cdn/3.jpg")}, true},
+		{"page replaced", entry, &grabber.Chapter{Number: 1, PagesCount: 2, Pages: pages("https://cdn/1.jpg", "https://cdn/2-fixed.jpg")}, true},
+		// imported chapters only know their page count
+		{"imported", Entry{Number: 1, Pages: 2}, &grabber.Chapter{Number: 1, PagesCount: 2, Pages: pages("https://cdn/x.jpg", "https://cdn/y.jpg")}, false},
+		// neither do they know the pages they were saved from: theirs may have
+		// been sliced (or restitched) before
+		{"imported sliced", Entry{Number: 1, Pages: 5}, &grabber.Chapter{Number: 1, PagesCount: 2, Pages: pages("https://cdn/x.jpg", "https://cdn/y.jpg")}, false},
+		{"saved sliced", Entry{Number: 1, Pages: 5, SourcePages: 2, PagesHash: PagesHash(saved)}, saved, false},
+	}
+	for _, c := range cases {
+		if got := c.entry.Outdated(c.chapter); got != c.want {
+			t.Errorf("%s: expected %v, got %v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestFindAndForget(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, dir, "Test 1.cbz")
+	touch(t, dir, "Test 2.cbz")
+
+	l, _ := Open(dir)
+	l.RecordFile(testURL, "Test", "Test 1.cbz", &grabber.Chapter{Number: 1})
+	l.RecordFile(testURL, "Test", "Test 2.cbz", &grabber.Chapter{Number: 2})
+
+	entry, ok := l.Find(testURL, &grabber.Chapter{Number: 2})
+	if !ok || entry.Filename != "Test 2.cbz" {
+		t.Fatalf("expected chapter 2's entry, got %+v", entry)
+	}
+
+	if err := l.Forget(testURL, entry); err != nil {
+		t.Fatalf("unexpected error forgetting: %s", err)
+	}
+	reopened, _ := Open(dir)
+	if _, ok := reopened.Find(testURL, &grabber.Chapter{Number: 2}); ok {
+		t.Error("expected chapter 2 to be forgotten")
+	}
+	if _, ok := reopened.Find(testURL, &grabber.Chapter{Number: 1}); !ok {
+		t.Error("expected chapter 1 to be kept")
+	}
+}
+
+func TestOnly(t *testing.T) {
+	l, _ := Open(t.TempDir())
+	if l.Only(testURL) {
+		t.Error("expected an empty ledger to hold no series of its own")
+	}
+
+	l.Record(testURL, "Test", Entry{Number: 1, Filename: "Test 1.cbz"})
+	if !l.Only(testURL) {
+		t.Error("expected the only series recorded to be told apart")
+	}
+
+	l.Record("https://example.com/manga/other", "Other", Entry{Number: 1, Filename: "Other 1.cbz"})
+	if l.Only(testURL) {
+		t.Error("expected a shared output dir not to be the series' own")
+	}
+}
@@ -0,0 +1,316 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/elboletaire/manga-downloader/grabber"
+	"github.com/elboletaire/manga-downloader/packer/pageopts"
+)
+
+// BundleLimits caps the files a bundle is saved into: a bundle going over them
+// is split into consecutive parts, at chapter boundaries (a single chapter
+// going over them gets a part of its own). Zero is no limit.
+type BundleLimits struct {
+	// Pages is the max pages of a part
+	Pages int
+	// Size is the max bytes of the pages of a part
+	Size int64
+}
+
+// BundlePart is a file a bundle was saved into
+type BundlePart struct {
+	// Name is the file name, relative to the output dir
+	Name string
+	// Chapters are the chapters in the file
+	Chapters []*DownloadedChapter
+	// Err is the error saving the file, if any (ErrSkipped when it was there
+	// already)
+	Err error
+}
+
+// BundleWriter packs bundles of downloaded chapters as the chapters come,
+// grouping each chapter's pages into its own folder inside the bundle
+// (Chapter 0001/000.jpg, ...) so chapter boundaries survive bundling. Each
+// chapter added is written into its bundle right away and its pages dropped,
+// so a bundle never needs to be held in memory whole.
+//
+// Bundling by range (BundleByRange), every chapter goes into a single bundle
+// named after rng. Bundling by volume (BundleByVolume), each volume's chapters
+// go into a bundle of their own, named after the range of chapters in it and
+// the volume itself: its Title is "Volume N", and the filename template can
+// use {{.Volume}}. The chapters without a volume are bundled apart, titled
+// "No volume".
+//
+// A bundle going over the limits is split into parts named after the range of
+// chapters in each ("Series 1-25", "Series 26-51"), every part being placed
+// (and its chapters' page spools removed) as soon as it's full.
+type BundleWriter struct {
+	outputdir string
+	s         grabber.Site
+	by        string
+	rng       string
+	limits    BundleLimits
+	progress  func(page, progress int)
+	// transforms rework every page before it's written
+	transforms pageTransforms
+	// cover are the pages every part starts with (see SetCover)
+	cover []File
+	// bundles are the bundles written, by volume (always "" by range)
+	bundles map[string]*bundle
+	// volumes are the keys of bundles, in the order the bundles started
+	volumes []string
+}
+
+// bundle is a bundle being written: the parts saved so far, and the one
+// being written
+type bundle struct {
+	// parts names the bundle; an empty Number is the range of each part's
+	// chapters
+	parts FilenameTemplateParts
+	done  []BundlePart
+	part  *bundlePart
+}
+
+// bundlePart is a part of a bundle being written, in a staging directory
+// until it's placed
+type bundlePart struct {
+	staging string
+	staged  string
+	w       pageWriter
+	meta    Metadata
+	// chapters are the chapters added to the part so far
+	chapters []*DownloadedChapter
+	// folders counts the chapters in each chapter folder name
+	folders map[string]int
+	pages   int
+	size    int64
+	// err is the first error writing the part, which loses every chapter
+	// in it
+	err error
+}
+
+// NewBundleWriter returns a writer saving the bundles of by (BundleByRange or
+// BundleByVolume) into outputdir, their pages reworked as pages says
+func NewBundleWriter(outputdir string, s grabber.Site, pages pageopts.Options, by, rng string, limits BundleLimits, progress func(page, progress int)) *BundleWriter {
+	return &BundleWriter{
+		outputdir:  outputdir,
+		s:          s,
+		by:         by,
+		rng:        rng,
+		limits:     limits,
+		progress:   progress,
+		transforms: newPageTransforms(s, pages),
+		bundles:    map[string]*bundle{},
+	}
+}
+
+// Add writes chapter into its bundle, first placing the part being written
+// when the chapter would take it over the limits. Chapters must be added in
+// order (by number), one at a time; the chapter's Files are emptied once
+// written. A chapter whose pages can't be reworked is left out, its error
+// returned; the errors writing the parts are returned by Close, along with
+// the part they lost.
+func (bw *BundleWriter) Add(chapter *DownloadedChapter) error {
+	reworked, err := bw.transforms.apply(chapter.Files)
+	if err != nil {
+		return fmt.Errorf("reworking the pages of %s: %w", chapter.GetTitle(), err)
+	}
+	b := bw.bundleOf(chapter)
+	// the limits are about the pages as written
+	pages := namePages(reworked, bw.s.GetConvertImages())
+
+	size := int64(0)
+	for _, page := range pages {
+		size += int64(len(page.Data))
+	}
+	if p := b.part; p != nil {
+		over := (bw.limits.Pages > 0 && p.pages+len(pages) > bw.limits.Pages) ||
+			(bw.limits.Size > 0 && p.size+size > bw.limits.Size)
+		if over {
+			bw.place(b, true)
+		}
+	}
+	if b.part == nil {
+		b.part = bw.newPart(b)
+	}
+
+	b.part.add(chapter, pages, perSourcePage(bw.progress, len(chapter.Files), len(pages)))
+	b.part.pages += len(pages)
+	b.part.size += size
+	chapter.Files = nil
+
+	return nil
+}
+
+// Close places the parts still being written, returning every part saved,
+// bundle by bundle (the chapters without a volume last)
+func (bw *BundleWriter) Close() []BundlePart {
+	volumes := slices.Clone(bw.volumes)
+	if i := slices.Index(volumes, ""); i >= 0 && bw.by == BundleByVolume {
+		volumes = append(slices.Delete(volumes, i, i+1), "")
+	}
+
+	parts := []BundlePart{}
+	for _, volume := range volumes {
+		b := bw.bundles[volume]
+		if b.part != nil {
+			bw.place(b, len(b.done) > 0)
+		}
+		parts = append(parts, b.done...)
+	}
+
+	return parts
+}
+
+// bundleOf returns the bundle chapter goes into, starting it if needed
+func (bw *BundleWriter) bundleOf(chapter *DownloadedChapter) *bundle {
+	volume := ""
+	if bw.by == BundleByVolume {
+		volume = chapter.GetVolume()
+	}
+	if b, ok := bw.bundles[volume]; ok {
+		return b
+	}
+
+	title, _ := bw.s.FetchTitle()
+	b := &bundle{parts: FilenameTemplateParts{Series: title, Number: bw.rng, Title: "bundle"}}
+	if bw.by == BundleByVolume {
+		b.parts = FilenameTemplateParts{Series: title, Title: "No volume", Volume: SanitizeFilename(volume)}
+		if volume != "" {
+			b.parts.Title = "Volume " + volume
+		}
+	}
+	bw.bundles[volume] = b
+	bw.volumes = append(bw.volumes, volume)
+
+	return b
+}
+
+// newPart starts writing a part of b
+func (bw *BundleWriter) newPart(b *bundle) *bundlePart {
+	p := &bundlePart{
+		folders: map[string]int{},
+		meta: Metadata{
+			Series:    b.parts.Series,
+			Volume:    b.parts.Volume,
+			Web:       bw.s.GetURL(),
+			Direction: bw.s.GetReadingDirection(),
+		},
+	}
+	p.staging, p.err = newStaging(bw.outputdir)
+	if p.err == nil {
+		p.staged = filepath.Join(p.staging, "bundle"+Extension(bw.s.GetFormat()))
+		p.w, p.err = newPageWriter(bw.s.GetFormat(), p.staged)
+	}
+	for _, page := range bw.cover {
+		if p.err == nil {
+			p.err = p.w.add(page)
+		}
+	}
+
+	return p
+}
+
+// add writes chapter's (named) pages into the part, in a folder of its own
+func (p *bundlePart) add(chapter *DownloadedChapter, pages []File, progress func(page, progress int)) {
+	// Several chapters can share a number (a site re-releasing the same chapter,
+	// or two genuinely distinct chapters both numbered, e.g. a pair of
+	// season-finale entries). Each chapter gets its own "Chapter 0001/" folder
+	// so chapter boundaries survive bundling, so repeated numbers are
+	// disambiguated with a " (2)", " (3)" suffix instead of silently merging
+	// both chapters' pages into one folder (which would lose the first one's).
+	base := SanitizeFilename(fmt.Sprintf("Chapter %s", paddedChapterNumber(chapter.GetNumber())))
+	folder := base
+	if n := p.folders[base]; n > 0 {
+		folder = fmt.Sprintf("%s (%d)", base, n+1)
+	}
+	p.folders[base]++
+	p.meta.Chapters = append(p.meta.Chapters, ChapterMetadata{Folder: folder, Number: chapter.GetNumber(), Title: chapter.GetTitle()})
+	if p.meta.Language == "" {
+		p.meta.Language = chapter.Language
+	}
+	p.chapters = append(p.chapters, chapter)
+
+	if p.err != nil {
+		return
+	}
+	for _, page := range pages {
+		if p.err = p.w.add(File{Name: fmt.Sprintf("%s/%s", folder, page.Name), Data: page.Data}); p.err != nil {
+			return
+		}
+		progress(1, 0) // Report progress by single page increments
+	}
+}
+
+// place finishes the part of b being written and moves it into place, named
+// after the range of its chapters when the bundle is split
+func (bw *BundleWriter) place(b *bundle, split bool) {
+	p := b.part
+	b.part = nil
+	defer os.RemoveAll(p.staging)
+
+	parts := b.parts
+	if split || parts.Number == "" {
+		parts.Number = chaptersRange(p.chapters)
+	}
+	p.meta.Number = parts.Number
+
+	part := BundlePart{Chapters: p.chapters}
+	part.Name, part.Err = bw.finish(p, parts)
+	if part.Err == nil || errors.Is(part.Err, ErrSkipped) {
+		for _, chapter := range p.chapters {
+			chapter.Spool.Remove()
+		}
+	}
+	b.done = append(b.done, part)
+}
+
+// finish closes the part's file and moves it into place, as pack does
+func (bw *BundleWriter) finish(p *bundlePart, parts FilenameTemplateParts) (string, error) {
+	if p.err == nil && p.pages == 0 {
+		p.err = errors.New("no files to pack")
+	}
+	if p.w != nil {
+		if err := p.w.close(p.meta); p.err == nil {
+			p.err = err
+		}
+	}
+
+	format, template, onExists := bw.s.GetFormat(), bw.s.GetFilenameTemplate(), bw.s.GetOnExists()
+	parts.Version = 1
+	name, err := packName(format, template, parts)
+	if err != nil {
+		return "", fmt.Errorf("- error creating filename for chapter %s: %s", p.meta.Series, err.Error())
+	}
+	if onExists == OnExistsSkip || onExists == OnExistsFail {
+		if exists(filepath.Join(bw.outputdir, name)) {
+			return collision(onExists, name)
+		}
+	}
+	if p.err != nil {
+		return "", fmt.Errorf("- error saving file %s: %s", name, p.err.Error())
+	}
+
+	return place(bw.outputdir, format, template, onExists, p.meta.Series, name, parts, p.staging, p.staged)
+}
+
+// chaptersRange returns the range of chapter numbers chapters (sorted by
+// number) cover, e.g. "1-25", or a single number
+func chaptersRange(chapters []*DownloadedChapter) string {
+	number := func(n float64) string {
+		return strings.Replace(fmt.Sprintf("%.1f", n), ".0", "", 1)
+	}
+	first, last := chapters[0].GetNumber(), chapters[len(chapters)-1].GetNumber()
+	if first == last {
+		return number(first)
+	}
+
+	return number(first) + "-" + number(last)
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"os"
+)
+
+// ArchiveCBT archives the given named files into a CBT file, an uncompressed
+// tar, along with a ComicInfo.xml describing them. Like CBZ archives, they're
+// reproducible (see ArchiveCBZ).
+func ArchiveCBT(filename string, meta Metadata, files []File, progress func(page, progress int)) error {
+	return writePages(FormatCBT, filename, meta, files, progress)
+}
+
+// cbtWriter writes a CBT file a page at a time, its ComicInfo.xml last
+type cbtWriter struct {
+	f *os.File
+	w *tar.Writer
+	// pages are the pages written so far, for the ComicInfo.xml
+	pages []pageEntry
+}
+
+func newCBTWriter(filename string) (*cbtWriter, error) {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cbtWriter{f: f, w: tar.NewWriter(f)}, nil
+}
+
+func (tw *cbtWriter) add(file File) error {
+	if err := tw.write(file.Name, file.Data); err != nil {
+		return err
+	}
+	tw.pages = append(tw.pages, newPageEntry(file))
+
+	return nil
+}
+
+func (tw *cbtWriter) close(meta Metadata) error {
+	defer tw.f.Close()
+
+	info, err := marshalComicInfo(meta, tw.pages)
+	if err != nil {
+		return err
+	}
+	if err = tw.write(ComicInfoName, info); err != nil {
+		return err
+	}
+
+	return tw.w.Close()
+}
+
+// write writes an entry, with no owner and the fixed entryModified time, so
+// the same pages always make the same archive
+func (tw *cbtWriter) write(name string, data []byte) error {
+	err := tw.w.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  entryModified,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = tw.w.Write(data)
+
+	return err
+}
+
+// countTarPages returns the number of pages in the CBT archive at path,
+// skipping over their data (see CountPages)
+func countTarPages(path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var pages int64
+	r := tar.NewReader(f)
+	for {
+		h, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return pages, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if h.Typeflag == tar.TypeReg && h.Name != ComicInfoName {
+			pages++
+		}
+	}
+}
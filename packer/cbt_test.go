@@ -0,0 +1,95 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tarEntries returns the entries of the tar at path, in order, and their data
+func tarEntries(t *testing.T, path string) ([]*tar.Header, map[string][]byte) {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	headers, data := []*tar.Header{}, map[string][]byte{}
+	r := tar.NewReader(f)
+	for {
+		h, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return headers, data
+		}
+		if err != nil {
+			t.Fatalf("reading tar: %v", err)
+		}
+		headers = append(headers, h)
+		data[h.Name], _ = io.ReadAll(r)
+	}
+}
+
+func TestArchiveCBTNoFiles(t *testing.T) {
+	err := ArchiveCBT(filepath.Join(t.TempDir(), "empty.cbt"), Metadata{}, []File{}, func(page, progress int) {})
+	if err == nil {
+		t.Fatal("expected an error when archiving zero files, got nil")
+	}
+}
+
+func TestArchiveCBT(t *testing.T) {
+	dir := t.TempDir()
+	meta := Metadata{Series: "Series", Number: "1-2", Chapters: []ChapterMetadata{{Folder: "Chapter 0001", Number: 1}, {Folder: "Chapter 0002", Number: 2}}}
+	files := []File{
+		{Name: "Chapter 0001/000.jpg", Data: []byte("ch1-p0")},
+		{Name: "Chapter 0001/001.jpg", Data: []byte("ch1-p1")},
+		{Name: "Chapter 0002/000.jpg", Data: []byte("ch2-p0")},
+	}
+
+	path := filepath.Join(dir, "bundle.cbt")
+	pages := 0
+	if err := ArchiveCBT(path, meta, files, func(page, progress int) { pages += page }); err != nil {
+		t.Fatalf("ArchiveCBT: %v", err)
+	}
+	if pages != len(files) {
+		t.Errorf("expected progress for every file, got %d", pages)
+	}
+
+	headers, data := tarEntries(t, path)
+	if len(headers) != len(files)+1 || headers[len(headers)-1].Name != ComicInfoName {
+		t.Fatalf("expected the pages in order, then ComicInfo.xml, got %d entries", len(headers))
+	}
+	for i, file := range files {
+		if headers[i].Name != file.Name || !bytes.Equal(data[file.Name], file.Data) {
+			t.Errorf("entry %d: expected %s", i, file.Name)
+		}
+		if !headers[i].ModTime.Equal(entryModified) {
+			t.Errorf("entry %d: expected the fixed time, got %s", i, headers[i].ModTime)
+		}
+	}
+	if !bytes.Contains(data[ComicInfoName], []byte(`Bookmark="Chapter 2"`)) {
+		t.Errorf("expected the bundle's pages listed in ComicInfo.xml")
+	}
+
+	if n, err := CountPages(path); err != nil || n != int64(len(files)) {
+		t.Errorf("CountPages = %d, %v; want %d", n, err, len(files))
+	}
+
+	// the same pages make the same archive
+	again := filepath.Join(dir, "again.cbt")
+	if err := ArchiveCBT(again, meta, files, func(page, progress int) {}); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := os.ReadFile(path)
+	second, _ := os.ReadFile(again)
+	if !bytes.Equal(first, second) {
+		t.Error("expected the same pages to make the same archive")
+	}
+}
@@ -4,10 +4,16 @@ package packer
 
 import (
 	"archive/zip"
-	"errors"
 	"os"
+	"time"
 )
 
+// entryModified is the modification time of every CBZ (and CBT) entry.
+// Chapters carry no release date to take it from, and the time of the
+// download would make the same chapter downloaded twice two different files,
+// so it's the earliest time zip can tell, always.
+var entryModified = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
 // File represents a named entry to be written into a CBZ archive
 type File struct {
 	// Name is the zip entry name (may include a directory prefix, e.g. "Chapter 0001/000.jpg")
@@ -16,29 +22,59 @@ type File struct {
 	Data []byte
 }
 
-// ArchiveCBZ archives the given named files into a CBZ file
-func ArchiveCBZ(filename string, files []File, progress func(page, progress int)) error {
-	if len(files) == 0 {
-		return errors.New("no files to pack")
+// ArchiveCBZ archives the given named files into a CBZ file, along with a
+// ComicInfo.xml describing them. Archives are reproducible: the same pages
+// make the same file, byte for byte, so they can be deduplicated (or synced)
+// by hash. Pages are stored as they are, in the order given, since images
+// are compressed already; ComicInfo.xml goes last.
+func ArchiveCBZ(filename string, meta Metadata, files []File, progress func(page, progress int)) error {
+	return writePages(FormatCBZ, filename, meta, files, progress)
+}
+
+// cbzWriter writes a CBZ file a page at a time, its ComicInfo.xml last
+type cbzWriter struct {
+	f *os.File
+	w *zip.Writer
+	// pages are the pages written so far, for the ComicInfo.xml
+	pages []pageEntry
+}
+
+func newCBZWriter(filename string) (*cbzWriter, error) {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cbzWriter{f: f, w: zip.NewWriter(f)}, nil
+}
+
+func (cw *cbzWriter) add(file File) error {
+	f, err := cw.w.CreateHeader(&zip.FileHeader{Name: file.Name, Method: zip.Store, Modified: entryModified})
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(file.Data); err != nil {
+		return err
+	}
+	cw.pages = append(cw.pages, newPageEntry(file))
+
+	return nil
+}
+
+func (cw *cbzWriter) close(meta Metadata) error {
+	defer cw.f.Close()
+
+	info, err := marshalComicInfo(meta, cw.pages)
+	if err != nil {
+		return err
 	}
-	buff, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+	f, err := cw.w.CreateHeader(&zip.FileHeader{Name: ComicInfoName, Method: zip.Deflate, Modified: entryModified})
 	if err != nil {
 		return err
 	}
-	defer buff.Close()
-	w := zip.NewWriter(buff)
-
-	for _, file := range files {
-		f, err := w.Create(file.Name)
-		if err != nil {
-			return err
-		}
-		if _, err = f.Write(file.Data); err != nil {
-			return err
-		}
-		progress(1, 0) // Report progress by single page increments
+	if _, err = f.Write(info); err != nil {
+		return err
 	}
 
-	err = w.Close()
-	return err
+	return cw.w.Close()
 }
@@ -4,6 +4,8 @@ package packer
 
 import (
 	"archive/zip"
+	"bytes"
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -12,7 +14,8 @@ import (
 	"github.com/spf13/cobra"
 )
 
-// entryNames opens the zip at path and returns its entry names, in order.
+// entryNames opens the zip at path and returns its page entry names, in
+// order (ComicInfo.xml left out, see TestArchiveCBZComicInfo).
 func entryNames(t *testing.T, path string) []string {
 	t.Helper()
 
@@ -22,15 +25,17 @@ func entryNames(t *testing.T, path string) []string {
 	}
 	defer r.Close()
 
-	names := make([]string, len(r.File))
-	for i, f := range r.File {
-		names[i] = f.Name
+	names := []string{}
+	for _, f := range r.File {
+		if f.Name != ComicInfoName {
+			names = append(names, f.Name)
+		}
 	}
 	return names
 }
 
 func TestArchiveCBZNoFiles(t *testing.T) {
-	err := ArchiveCBZ(filepath.Join(t.TempDir(), "empty.cbz"), []File{}, func(page, progress int) {})
+	err := ArchiveCBZ(filepath.Join(t.TempDir(), "empty.cbz"), Metadata{}, []File{}, func(page, progress int) {})
 	if err == nil {
 		t.Fatal("expected an error when archiving zero files, got nil")
 	}
@@ -46,7 +51,7 @@ func TestArchiveCBZEntryNames(t *testing.T) {
 	}
 
 	path := filepath.Join(t.TempDir(), "bundle.cbz")
-	if err := ArchiveCBZ(path, files, func(page, progress int) {}); err != nil {
+	if err := ArchiveCBZ(path, Metadata{}, files, func(page, progress int) {}); err != nil {
 		t.Fatalf("ArchiveCBZ: %v", err)
 	}
 
@@ -73,6 +78,46 @@ func TestArchiveCBZEntryNames(t *testing.T) {
 	}
 }
 
+func TestArchiveCBZReproducible(t *testing.T) {
+	dir := t.TempDir()
+	meta := Metadata{Series: "Series", Number: "1"}
+	files := []File{
+		{Name: "000.jpg", Data: []byte("page 0")},
+		{Name: "001.png", Data: []byte("page 1")},
+	}
+
+	archive := func(name string) []byte {
+		path := filepath.Join(dir, name)
+		if err := ArchiveCBZ(path, meta, files, func(page, progress int) {}); err != nil {
+			t.Fatalf("ArchiveCBZ: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	if !bytes.Equal(archive("first.cbz"), archive("second.cbz")) {
+		t.Error("expected the same pages to make the same archive")
+	}
+
+	r, err := zip.OpenReader(filepath.Join(dir, "first.cbz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	// built back to back, the archives only differ by their times when the
+	// clock ticks in between: those are told fixed by the headers instead
+	for _, f := range r.File {
+		if f.Name != ComicInfoName && f.Method != zip.Store {
+			t.Errorf("expected page %s stored, not compressed", f.Name)
+		}
+		if !f.Modified.Equal(entryModified) {
+			t.Errorf("expected %s to have the fixed time, got %s", f.Name, f.Modified)
+		}
+	}
+}
+
 func TestPaddedChapterNumber(t *testing.T) {
 	cases := []struct {
 		in   float64
@@ -94,12 +139,13 @@ func TestPaddedChapterNumber(t *testing.T) {
 	}
 }
 
-// fakeSite is a minimal grabber.Site implementation for testing PackBundle's
+// fakeSite is a minimal grabber.Site implementation for testing BundleWriter's
 // entry-naming behavior without hitting a real site.
 type fakeSite struct {
 	title    string
 	template string
 	convert  grabber.ConvertFormats
+	onExists string
 }
 
 func (f *fakeSite) InitFlags(cmd *cobra.Command)                  {}
@@ -110,11 +156,19 @@ func (f *fakeSite) FetchChapter(grabber.Filterable) (*grabber.Chapter, error) {
 }
 func (f *fakeSite) FetchTitle() (string, error) { return f.title, nil }
 func (f *fakeSite) BaseUrl() string             { return "https://example.com" }
+func (f *fakeSite) GetURL() string              { return "https://example.com/series" }
 func (f *fakeSite) GetFilenameTemplate() string { return f.template }
 func (f *fakeSite) GetFormat() string           { return FormatCBZ }
+func (f *fakeSite) GetReadingDirection() string { return DirectionRTL }
 func (f *fakeSite) GetConvertImages() grabber.ConvertFormats {
 	return f.convert
 }
+func (f *fakeSite) GetOnExists() string {
+	if f.onExists == "" {
+		return OnExistsVersion
+	}
+	return f.onExists
+}
 func (f *fakeSite) GetMaxConcurrency() grabber.MaxConcurrency {
 	return grabber.MaxConcurrency{Chapters: 1, Pages: 1}
 }
@@ -140,9 +194,9 @@ func TestPackBundleConvertsPages(t *testing.T) {
 	}
 
 	dir := t.TempDir()
-	filename, err := PackBundle(dir, site, chapters, "1", func(page, progress int) {})
+	filename, err := singlePart(t, writeBundle(dir, site, BundleByRange, "1", BundleLimits{}, chapters...))
 	if err != nil {
-		t.Fatalf("PackBundle: %v", err)
+		t.Fatalf("BundleWriter: %v", err)
 	}
 
 	names := entryNames(t, filepath.Join(dir, filename))
@@ -171,9 +225,9 @@ func TestPackBundleEntryNames(t *testing.T) {
 	}
 
 	dir := t.TempDir()
-	filename, err := PackBundle(dir, site, chapters, "1-10.5", func(page, progress int) {})
+	filename, err := singlePart(t, writeBundle(dir, site, BundleByRange, "1-10.5", BundleLimits{}, chapters...))
 	if err != nil {
-		t.Fatalf("PackBundle: %v", err)
+		t.Fatalf("BundleWriter: %v", err)
 	}
 
 	names := entryNames(t, filepath.Join(dir, filename))
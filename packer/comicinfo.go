@@ -0,0 +1,120 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image"
+	"strconv"
+	"strings"
+
+	// registers the decoders imageSize needs (jpeg is registered by
+	// convert.go already, avif and webp as well)
+	_ "image/gif"
+	_ "image/png"
+)
+
+// ComicInfoName is the name of the metadata entry of CBZ archives
+const ComicInfoName = "ComicInfo.xml"
+
+// comicInfo is the ComicInfo.xml document media servers (Komga, Kavita...)
+// and most readers get a CBZ's metadata from. Fields follow the order of the
+// ComicInfo schema, which some readers validate against.
+type comicInfo struct {
+	XMLName     xml.Name        `xml:"ComicInfo"`
+	XSI         string          `xml:"xmlns:xsi,attr"`
+	XSD         string          `xml:"xmlns:xsd,attr"`
+	Title       string          `xml:"Title,omitempty"`
+	Series      string          `xml:"Series,omitempty"`
+	Number      string          `xml:"Number,omitempty"`
+	Volume      int             `xml:"Volume,omitempty"`
+	Web         string          `xml:"Web,omitempty"`
+	PageCount   int             `xml:"PageCount"`
+	LanguageISO string          `xml:"LanguageISO,omitempty"`
+	Pages       []comicInfoPage `xml:"Pages>Page,omitempty"`
+}
+
+// comicInfoPage describes a page of a bundle
+type comicInfoPage struct {
+	Image       int    `xml:"Image,attr"`
+	Type        string `xml:"Type,attr,omitempty"`
+	ImageWidth  int    `xml:"ImageWidth,attr,omitempty"`
+	ImageHeight int    `xml:"ImageHeight,attr,omitempty"`
+	Bookmark    string `xml:"Bookmark,attr,omitempty"`
+}
+
+// pageEntry is what's kept of a page once it's written: what the metadata
+// written after every page (ComicInfo.xml) needs of it
+type pageEntry struct {
+	// name is the page's name in the archive
+	name string
+	// size is the image's size, zero when it can't be told
+	size image.Point
+}
+
+// newPageEntry returns the entry of file
+func newPageEntry(file File) pageEntry {
+	size, _ := imageSize(file.Data)
+
+	return pageEntry{name: file.Name, size: size}
+}
+
+// marshalComicInfo returns the ComicInfo.xml document of the given pages.
+// Bundles list their pages, with their sizes, bookmarking each chapter's
+// first page, so readers can jump between the bundled chapters.
+func marshalComicInfo(meta Metadata, pages []pageEntry) ([]byte, error) {
+	info := comicInfo{
+		XSI:         "http://www.w3.org/2001/XMLSchema-instance",
+		XSD:         "http://www.w3.org/2001/XMLSchema",
+		Title:       meta.Title,
+		Series:      meta.Series,
+		Number:      meta.Number,
+		Web:         meta.Web,
+		PageCount:   len(pages),
+		LanguageISO: meta.Language,
+	}
+	// the schema only takes whole volume numbers
+	if volume, err := strconv.Atoi(meta.Volume); err == nil {
+		info.Volume = volume
+	}
+
+	if len(meta.Chapters) > 0 {
+		labels := map[string]string{}
+		for _, chapter := range meta.Chapters {
+			labels[chapter.Folder] = chapterLabel(chapter.Number, chapter.Title)
+		}
+		folder := "\x00"
+		for i, entry := range pages {
+			page := comicInfoPage{Image: i, ImageWidth: entry.size.X, ImageHeight: entry.size.Y}
+			dir, _, inFolder := strings.Cut(entry.name, "/")
+			if !inFolder {
+				// the pages outside of the chapter folders are the cover's
+				// (see BundleWriter.SetCover)
+				page.Type = "FrontCover"
+			} else if dir != folder {
+				folder = dir
+				page.Bookmark = labels[dir]
+			}
+			info.Pages = append(info.Pages, page)
+		}
+	}
+
+	data, err := xml.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), data...), nil
+}
+
+// imageSize returns the dimensions of an encoded image, read from its header
+// alone, or false if its format can't be told
+func imageSize(data []byte) (image.Point, bool) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return image.Point{}, false
+	}
+
+	return image.Point{X: cfg.Width, Y: cfg.Height}, true
+}
@@ -0,0 +1,107 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+// readComicInfo returns the ComicInfo.xml of the CBZ at path
+func readComicInfo(t *testing.T, path string) comicInfo {
+	t.Helper()
+
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("opening archive: %v", err)
+	}
+	defer r.Close()
+
+	f, err := r.Open(ComicInfoName)
+	if err != nil {
+		t.Fatalf("expected a %s entry: %v", ComicInfoName, err)
+	}
+	defer f.Close()
+	data, _ := io.ReadAll(f)
+
+	info := comicInfo{}
+	if err := xml.Unmarshal(data, &info); err != nil {
+		t.Fatalf("parsing %s: %v", ComicInfoName, err)
+	}
+	return info
+}
+
+func TestArchiveCBZComicInfo(t *testing.T) {
+	meta := Metadata{
+		Series:   "Series",
+		Number:   "10.5",
+		Title:    "Extra",
+		Language: "es",
+		Web:      "https://example.com/series",
+	}
+	path := filepath.Join(t.TempDir(), "single.cbz")
+	files := []File{{Name: "000.jpg", Data: []byte("p0")}, {Name: "001.jpg", Data: []byte("p1")}}
+	if err := ArchiveCBZ(path, meta, files, func(page, progress int) {}); err != nil {
+		t.Fatalf("ArchiveCBZ: %v", err)
+	}
+
+	info := readComicInfo(t, path)
+	want := comicInfo{
+		Title:       "Extra",
+		Series:      "Series",
+		Number:      "10.5",
+		Web:         "https://example.com/series",
+		PageCount:   2,
+		LanguageISO: "es",
+	}
+	if info.Title != want.Title || info.Series != want.Series || info.Number != want.Number ||
+		info.Web != want.Web || info.PageCount != want.PageCount || info.LanguageISO != want.LanguageISO {
+		t.Errorf("expected %+v, got %+v", want, info)
+	}
+	if len(info.Pages) != 0 {
+		t.Errorf("expected no page list for a single chapter, got %+v", info.Pages)
+	}
+
+	if pages, _ := CountPages(path); pages != 2 {
+		t.Errorf("expected ComicInfo.xml not to count as a page, got %d pages", pages)
+	}
+}
+
+func TestArchiveCBZComicInfoBundle(t *testing.T) {
+	meta := Metadata{
+		Series: "Series",
+		Number: "1-2",
+		Chapters: []ChapterMetadata{
+			{Folder: "Chapter 0001", Number: 1, Title: "Start"},
+			{Folder: "Chapter 0002", Number: 2},
+		},
+	}
+	files := []File{
+		{Name: "Chapter 0001/000.png", Data: pngBytes(t, gradientImage(8, 12))},
+		{Name: "Chapter 0001/001.jpg", Data: []byte("not an image")},
+		{Name: "Chapter 0002/000.jpg", Data: jpegBytes(t, gradientImage(16, 10))},
+	}
+	path := filepath.Join(t.TempDir(), "bundle.cbz")
+	if err := ArchiveCBZ(path, meta, files, func(page, progress int) {}); err != nil {
+		t.Fatalf("ArchiveCBZ: %v", err)
+	}
+
+	want := []comicInfoPage{
+		{Image: 0, ImageWidth: 8, ImageHeight: 12, Bookmark: "Chapter 1: Start"},
+		// an undecodable page is listed, without a size
+		{Image: 1},
+		{Image: 2, ImageWidth: 16, ImageHeight: 10, Bookmark: "Chapter 2"},
+	}
+	info := readComicInfo(t, path)
+	if len(info.Pages) != len(want) {
+		t.Fatalf("expected %d pages, got %+v", len(want), info.Pages)
+	}
+	for i := range want {
+		if info.Pages[i] != want[i] {
+			t.Errorf("page %d: expected %+v, got %+v", i, want[i], info.Pages[i])
+		}
+	}
+}
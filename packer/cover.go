@@ -0,0 +1,91 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/elboletaire/manga-downloader/downloader"
+	"github.com/fatih/color"
+)
+
+// Where the series cover goes (see grabber.Settings.Cover)
+const (
+	// CoverNone leaves the cover out
+	CoverNone = "none"
+	// CoverBundle prepends the cover to every bundle file, as its page 000
+	CoverBundle = "bundle"
+	// CoverFile saves the cover next to the files, as CoverFiles
+	CoverFile = "file"
+	// CoverBoth does both CoverBundle and CoverFile
+	CoverBoth = "both"
+)
+
+// CoverModes lists every supported cover mode
+var CoverModes = []string{CoverNone, CoverBundle, CoverFile, CoverBoth}
+
+// CoverFiles are the names the cover is saved as by CoverFile: media servers
+// (Komga, Kavita, Jellyfin, Kodi...) look for one or the other to show as the
+// folder's thumbnail, instead of its first file's first page
+var CoverFiles = []string{"cover.jpg", "folder.jpg"}
+
+// SaveCover saves the cover image as each of CoverFiles inside outputdir,
+// converted to JPEG first when it's in any other format. Existing files are
+// replaced, through a temporary file, so the cover is refreshed when the site
+// changes it and an interrupted write never leaves a broken one behind.
+func SaveCover(outputdir string, data []byte) error {
+	if extFromContent(data) != "jpg" {
+		converted, err := convertToJPEG(data)
+		if err != nil {
+			return err
+		}
+		data = converted
+	}
+
+	errs := []error{}
+	for _, name := range CoverFiles {
+		errs = append(errs, replaceFile(filepath.Join(outputdir, name), data))
+	}
+
+	return errors.Join(errs...)
+}
+
+// replaceFile writes data to path through a temporary file in the same
+// directory, renamed over path once it's complete
+func replaceFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// SetCover makes cover (an image) the first page of every bundle file written
+// from now on, outside of the chapter folders (000.jpg), so readers and media
+// servers show it as the thumbnail. It's reworked and converted as the
+// pages are (see namePages); a cover that can't be is left out, with a
+// warning, as failing to download it is.
+func (bw *BundleWriter) SetCover(cover []byte) {
+	reworked, err := bw.transforms.apply([]*downloader.File{{Data: cover}})
+	if err != nil {
+		color.Yellow("- warning: could not rework the cover, leaving it out: %s", err.Error())
+		return
+	}
+	bw.cover = namePages(reworked, bw.s.GetConvertImages())
+}
@@ -0,0 +1,81 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elboletaire/manga-downloader/downloader"
+	"github.com/elboletaire/manga-downloader/grabber"
+	"github.com/elboletaire/manga-downloader/packer/pageopts"
+)
+
+func TestSaveCover(t *testing.T) {
+	dir := t.TempDir()
+	stale := []byte("an older cover")
+	if err := os.WriteFile(filepath.Join(dir, "folder.jpg"), stale, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SaveCover(dir, pngBytes(t, gradientImage(4, 6))); err != nil {
+		t.Fatal(err)
+	}
+
+	cover, err := os.ReadFile(filepath.Join(dir, "cover.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if extFromContent(cover) != "jpg" {
+		t.Errorf("expected the cover converted to jpeg")
+	}
+	if folder, _ := os.ReadFile(filepath.Join(dir, "folder.jpg")); !bytes.Equal(folder, cover) {
+		t.Errorf("expected the existing folder.jpg replaced by the cover")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != len(CoverFiles) {
+		t.Errorf("expected the cover files alone in the dir, got %d entries", len(entries))
+	}
+}
+
+func TestBundleWriterCover(t *testing.T) {
+	dir := t.TempDir()
+	site := &fakeSite{title: "Series", template: "{{.Series}} {{.Number}}"}
+	jpg := jpegBytes(t, gradientImage(4, 6))
+	chapter := func(number float64) *DownloadedChapter {
+		return &DownloadedChapter{Chapter: &grabber.Chapter{Number: number}, Files: []*downloader.File{{Data: jpg}}}
+	}
+
+	w := NewBundleWriter(dir, site, pageopts.Options{}, BundleByRange, "1-2", BundleLimits{Pages: 1}, func(page, progress int) {})
+	w.SetCover(jpg)
+	w.Add(chapter(1))
+	w.Add(chapter(2))
+	parts := w.Close()
+	if len(parts) != 2 {
+		t.Fatalf("expected 2 parts, got %+v", parts)
+	}
+
+	// every part starts with the cover, which isn't counted against the limits
+	for _, part := range parts {
+		path := filepath.Join(dir, part.Name)
+		r, err := zip.OpenReader(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := []string{}
+		for _, f := range r.File {
+			names = append(names, f.Name)
+		}
+		r.Close()
+		if len(names) != 3 || names[0] != "000.jpg" {
+			t.Errorf("%s: expected the cover as the first page, got %v", part.Name, names)
+		}
+
+		info := readComicInfo(t, path)
+		if len(info.Pages) != 2 || info.Pages[0].Type != "FrontCover" || info.Pages[1].Type != "" {
+			t.Errorf("%s: expected the cover page typed FrontCover, got %+v", part.Name, info.Pages)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/elboletaire/manga-downloader/packer/pageopts"
+)
+
+// cropMargins returns img without its margins: the rows and columns at its
+// edges of a single colour (give or take c.Tolerance), as the white or black
+// borders of a scan are. Cropping stops at the first row or column with a
+// single pixel of another colour, so content is never cut into. A page the
+// crop would leave less than c.MinRetained of (a mostly blank one), or that
+// has no margins, is returned as it is.
+func cropMargins(img image.Image, c pageopts.Crop) image.Image {
+	bounds := img.Bounds()
+	content := contentBounds(img, c.Tolerance)
+	if content.Empty() || content == bounds {
+		return img
+	}
+	if float64(content.Dx()) < c.MinRetained*float64(bounds.Dx()) ||
+		float64(content.Dy()) < c.MinRetained*float64(bounds.Dy()) {
+		return img
+	}
+
+	return subImage(img, content)
+}
+
+// contentBounds returns the part of img within its margins, each edge's margin
+// being the colour of the middle of the edge. It's empty for a blank image.
+func contentBounds(img image.Image, tolerance int) image.Rectangle {
+	r := img.Bounds()
+	if r.Empty() {
+		return r
+	}
+	midX, midY := r.Min.X+r.Dx()/2, r.Min.Y+r.Dy()/2
+	top, bottom := rgbaAt(img, midX, r.Min.Y), rgbaAt(img, midX, r.Max.Y-1)
+	left, right := rgbaAt(img, r.Min.X, midY), rgbaAt(img, r.Max.X-1, midY)
+
+	for r.Dy() > 0 && uniform(img, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+1), top, tolerance) {
+		r.Min.Y++
+	}
+	for r.Dy() > 0 && uniform(img, image.Rect(r.Min.X, r.Max.Y-1, r.Max.X, r.Max.Y), bottom, tolerance) {
+		r.Max.Y--
+	}
+	// the sides are only looked at between the rows left, so a page number
+	// in the bottom margin doesn't stop them being cropped
+	for r.Dx() > 0 && r.Dy() > 0 && uniform(img, image.Rect(r.Min.X, r.Min.Y, r.Min.X+1, r.Max.Y), left, tolerance) {
+		r.Min.X++
+	}
+	for r.Dx() > 0 && r.Dy() > 0 && uniform(img, image.Rect(r.Max.X-1, r.Min.Y, r.Max.X, r.Max.Y), right, tolerance) {
+		r.Max.X--
+	}
+	if r.Dx() <= 0 || r.Dy() <= 0 {
+		return image.Rectangle{}
+	}
+
+	return r
+}
+
+// uniform reports whether every pixel of img within line (a row or a column)
+// is of colour ref, give or take tolerance on each channel
+func uniform(img image.Image, line image.Rectangle, ref color.RGBA, tolerance int) bool {
+	near := func(a, b uint8) bool {
+		return max(a, b)-min(a, b) <= uint8(tolerance)
+	}
+	for y := line.Min.Y; y < line.Max.Y; y++ {
+		for x := line.Min.X; x < line.Max.X; x++ {
+			c := rgbaAt(img, x, y)
+			if !near(c.R, ref.R) || !near(c.G, ref.G) || !near(c.B, ref.B) || !near(c.A, ref.A) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// rgbaAt returns the colour of img's pixel at (x, y), in 8 bits per channel
+func rgbaAt(img image.Image, x, y int) color.RGBA {
+	return color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+}
@@ -0,0 +1,98 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/elboletaire/manga-downloader/downloader"
+	"github.com/elboletaire/manga-downloader/packer/pageopts"
+)
+
+var defaultCrop = pageopts.Crop{Auto: true, Tolerance: pageopts.AutoCropToleranceDefault, MinRetained: pageopts.AutoCropMinRetainedDefault}
+
+// framedImage returns a w by h image of margin colour, with a gradient (the
+// content) filling content
+func framedImage(w, h int, margin color.Color, content image.Rectangle) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(margin), image.Point{}, draw.Src)
+	for y := content.Min.Y; y < content.Max.Y; y++ {
+		for x := content.Min.X; x < content.Max.X; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(x * 6), G: uint8(y * 4), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func TestCropMargins(t *testing.T) {
+	white, black := color.RGBA{255, 255, 255, 255}, color.RGBA{A: 255}
+	for name, test := range map[string]struct {
+		img  image.Image
+		want image.Rectangle
+	}{
+		"white":        {framedImage(40, 60, white, image.Rect(10, 12, 30, 50)), image.Rect(10, 12, 30, 50)},
+		"black":        {framedImage(40, 60, black, image.Rect(5, 5, 35, 55)), image.Rect(5, 5, 35, 55)},
+		"bleeding":     {framedImage(40, 60, white, image.Rect(0, 12, 30, 60)), image.Rect(0, 12, 30, 60)},
+		"no margins":   {framedImage(16, 16, white, image.Rect(0, 0, 16, 16)), image.Rect(0, 0, 16, 16)},
+		"blank":        {framedImage(40, 60, white, image.Rectangle{}), image.Rect(0, 0, 40, 60)},
+		"mostly blank": {framedImage(40, 60, white, image.Rect(18, 28, 22, 32)), image.Rect(0, 0, 40, 60)},
+	} {
+		if got := cropMargins(test.img, defaultCrop).Bounds(); got != test.want {
+			t.Errorf("%s: expected %v, got %v", name, test.want, got)
+		}
+	}
+}
+
+func TestCropMarginsTolerance(t *testing.T) {
+	img := framedImage(40, 60, color.RGBA{255, 255, 255, 255}, image.Rect(10, 10, 30, 50))
+	// scanner noise in the margins
+	for x := 0; x < 40; x += 3 {
+		img.Set(x, 4, color.RGBA{240, 240, 240, 255})
+	}
+
+	if got := cropMargins(img, defaultCrop).Bounds(); got != image.Rect(10, 10, 30, 50) {
+		t.Errorf("expected the noise cropped along with the margins, got %v", got)
+	}
+	exact := defaultCrop
+	exact.Tolerance = 0
+	if got := cropMargins(img, exact).Bounds(); got != image.Rect(0, 4, 40, 50) {
+		t.Errorf("expected the noise kept as content, without tolerance, got %v", got)
+	}
+}
+
+func TestCropMarginsMinRetained(t *testing.T) {
+	img := framedImage(40, 60, color.RGBA{255, 255, 255, 255}, image.Rect(10, 15, 30, 45))
+
+	if got := cropMargins(img, defaultCrop).Bounds(); got != image.Rect(10, 15, 30, 45) {
+		t.Errorf("expected half the page cropped, got %v", got)
+	}
+	strict := defaultCrop
+	strict.MinRetained = 0.6
+	if got := cropMargins(img, strict).Bounds(); got != img.Bounds() {
+		t.Errorf("expected the page kept whole, got %v", got)
+	}
+}
+
+func TestPageTransformsCrop(t *testing.T) {
+	framed := framedImage(40, 60, color.RGBA{255, 255, 255, 255}, image.Rect(10, 10, 30, 50))
+	unframed := pngBytes(t, gradientImage(16, 16))
+	pages := []*downloader.File{{Data: pngBytes(t, framed), Page: 1}, {Data: unframed, Page: 2}}
+
+	got := applied(t, pageTransforms{Options: pageopts.Options{Crop: defaultCrop}}, pages)
+	if len(got) != 2 || &got[1].Data[0] != &unframed[0] {
+		t.Fatalf("expected the page without margins untouched")
+	}
+	img, err := decodeImage(got[0].Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size != image.Pt(20, 40) {
+		t.Errorf("expected the page cropped to 20x40, got %v", size)
+	}
+	if extFromContent(got[0].Data) != "png" {
+		t.Errorf("expected the cropped page kept as png")
+	}
+}
@@ -0,0 +1,379 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+)
+
+// Reading directions (see grabber.Settings.ReadingDirection)
+const (
+	DirectionRTL = "rtl"
+	DirectionLTR = "ltr"
+)
+
+// Directions lists every supported reading direction
+var Directions = []string{DirectionRTL, DirectionLTR}
+
+// epubPageSize is the size given to a page whose image can't be decoded,
+// roughly the aspect ratio of a manga page
+var epubPageSize = image.Point{X: 1000, Y: 1500}
+
+// epubMediaTypes maps the page extensions to their media types
+var epubMediaTypes = map[string]string{
+	"jpg":  "image/jpeg",
+	"png":  "image/png",
+	"gif":  "image/gif",
+	"webp": "image/webp",
+	"avif": "image/avif",
+}
+
+// epubPage is a page of the book: an image and the XHTML document showing it
+type epubPage struct {
+	// N is the page number, from 1
+	N int
+	// Image is the image's path inside the book
+	Image string
+	// MediaType is the image's media type
+	MediaType string
+	// Width and Height are the image's dimensions, the page's viewport
+	Width, Height int
+	// folder is the folder of the page's chapter in a bundle, empty otherwise
+	folder string
+}
+
+// ID returns the manifest id of the page's XHTML document
+func (p epubPage) ID() string {
+	return fmt.Sprintf("page-%04d", p.N)
+}
+
+// Href returns the path of the page's XHTML document inside the book
+func (p epubPage) Href() string {
+	return fmt.Sprintf("pages/%04d.xhtml", p.N)
+}
+
+// epubNavEntry is an entry of the table of contents
+type epubNavEntry struct {
+	Label string
+	Href  string
+}
+
+// epubBook is what the book's documents are rendered from
+type epubBook struct {
+	ID        string
+	Title     string
+	Meta      Metadata
+	Language  string
+	Direction string
+	Modified  string
+	Pages     []epubPage
+	Nav       []epubNavEntry
+}
+
+// ArchiveEPUB archives the given named files into a fixed-layout EPUB 3 file,
+// one XHTML page per image, sized to the image. The table of contents has an
+// entry per chapter: the bundled chapters, by the folder their pages are in,
+// or the single chapter otherwise.
+func ArchiveEPUB(filename string, meta Metadata, files []File, progress func(page, progress int)) error {
+	return writePages(FormatEPUB, filename, meta, files, progress)
+}
+
+// epubWriter writes an EPUB file a page at a time, the documents listing
+// them (the package document and the table of contents) last
+type epubWriter struct {
+	f     *os.File
+	w     *zip.Writer
+	pages []epubPage
+}
+
+func newEPUBWriter(filename string) (*epubWriter, error) {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return nil, err
+	}
+	ew := &epubWriter{f: f, w: zip.NewWriter(f)}
+
+	// the mimetype goes first and uncompressed, so it can be told apart by its
+	// first bytes alone
+	mimetype, err := ew.w.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store, Modified: entryModified})
+	if err == nil {
+		_, err = mimetype.Write([]byte("application/epub+zip"))
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return ew, nil
+}
+
+func (ew *epubWriter) add(file File) error {
+	page := newEPUBPage(len(ew.pages)+1, file)
+	if err := writeEPUBEntry(ew.w, "OEBPS/"+page.Image, file.Data); err != nil {
+		return err
+	}
+	if err := renderEPUBEntry(ew.w, "OEBPS/"+page.Href(), epubPageTemplate, page); err != nil {
+		return err
+	}
+	ew.pages = append(ew.pages, page)
+
+	return nil
+}
+
+func (ew *epubWriter) close(meta Metadata) error {
+	defer ew.f.Close()
+
+	book := newEPUBBook(meta, ew.pages)
+	if err := writeEPUBEntry(ew.w, "META-INF/container.xml", []byte(epubContainer)); err != nil {
+		return err
+	}
+	if err := renderEPUBEntry(ew.w, "OEBPS/content.opf", epubPackageTemplate, book); err != nil {
+		return err
+	}
+	if err := renderEPUBEntry(ew.w, "OEBPS/nav.xhtml", epubNavTemplate, book); err != nil {
+		return err
+	}
+
+	return ew.w.Close()
+}
+
+// newEPUBPage returns the n-th page of the book, showing file
+func newEPUBPage(n int, file File) epubPage {
+	ext := strings.TrimPrefix(path.Ext(file.Name), ".")
+	page := epubPage{
+		N:         n,
+		Image:     fmt.Sprintf("images/%04d.%s", n, ext),
+		MediaType: epubMediaTypes[ext],
+	}
+	if page.MediaType == "" {
+		page.MediaType = "image/jpeg"
+	}
+	size, ok := imageSize(file.Data)
+	if !ok {
+		size = epubPageSize
+	}
+	page.Width, page.Height = size.X, size.Y
+	if dir, _, bundled := strings.Cut(file.Name, "/"); bundled {
+		page.folder = dir
+	}
+
+	return page
+}
+
+// newEPUBBook lays out the book of the given pages: its metadata and its
+// table of contents
+func newEPUBBook(meta Metadata, pages []epubPage) epubBook {
+	title := strings.TrimSpace(meta.Series + " " + meta.Number)
+	if meta.Title != "" {
+		title += " - " + meta.Title
+	}
+	language := meta.Language
+	if language == "" {
+		language = "und"
+	}
+	direction := meta.Direction
+	if direction != DirectionLTR {
+		direction = DirectionRTL
+	}
+	book := epubBook{
+		// the same chapter always gets the same id, so readers syncing
+		// progress by id keep it across downloads
+		ID:        fmt.Sprintf("urn:uuid:%s", nameUUID(meta.Series+"\x00"+meta.Number+"\x00"+meta.Title)),
+		Title:     title,
+		Meta:      meta,
+		Language:  language,
+		Direction: direction,
+		// a fixed time, as the CBZ entries have, so the same pages always
+		// make the same book
+		Modified: entryModified.Format("2006-01-02T15:04:05Z"),
+		Pages:    pages,
+	}
+
+	labels := map[string]string{}
+	for _, chapter := range meta.Chapters {
+		labels[chapter.Folder] = chapterLabel(chapter.Number, chapter.Title)
+	}
+
+	folder := "\x00"
+	for _, page := range pages {
+		if page.folder == folder {
+			continue
+		}
+		folder = page.folder
+
+		label, ok := labels[folder]
+		switch {
+		case ok:
+		case folder != "":
+			label = folder
+		case len(meta.Chapters) > 0:
+			// a bundle's pages outside of the chapter folders are its cover's
+			label = "Cover"
+		case meta.Title != "":
+			label = meta.Title
+		default:
+			label = title
+		}
+		book.Nav = append(book.Nav, epubNavEntry{Label: label, Href: page.Href()})
+	}
+
+	return book
+}
+
+// chapterLabel returns the label of a bundled chapter in tables of contents
+// and bookmarks
+func chapterLabel(number float64, title string) string {
+	if title == "" {
+		return fmt.Sprintf("Chapter %g", number)
+	}
+
+	return fmt.Sprintf("Chapter %g: %s", number, title)
+}
+
+// nameUUID returns a version 5 style UUID derived from name
+func nameUUID(name string) string {
+	sum := sha1.Sum([]byte(name))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// countEPUBPages returns the number of pages in the EPUB book at path: the
+// images in it, read from its central directory (see CountPages)
+func countEPUBPages(path string) (int64, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	var pages int64
+	for _, f := range r.File {
+		if strings.HasPrefix(f.Name, "OEBPS/images/") && !f.FileInfo().IsDir() {
+			pages++
+		}
+	}
+
+	return pages, nil
+}
+
+// writeEPUBEntry writes a (compressed) entry into the book
+func writeEPUBEntry(w *zip.Writer, name string, data []byte) error {
+	f, err := createEPUBEntry(w, name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+
+	return err
+}
+
+// renderEPUBEntry renders templ with data into a new entry of the book
+func renderEPUBEntry(w *zip.Writer, name string, templ *template.Template, data any) error {
+	f, err := createEPUBEntry(w, name)
+	if err != nil {
+		return err
+	}
+
+	return templ.Execute(f, data)
+}
+
+// createEPUBEntry creates a compressed entry of the book, with the fixed
+// entryModified time
+func createEPUBEntry(w *zip.Writer, name string) (io.Writer, error) {
+	return w.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: entryModified})
+}
+
+// xmlEscape escapes s to be used as XML text or attribute value
+func xmlEscape(s string) string {
+	buf := &strings.Builder{}
+	xml.EscapeText(buf, []byte(s))
+
+	return buf.String()
+}
+
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+var epubFuncs = template.FuncMap{"x": xmlEscape}
+
+var epubPackageTemplate = template.Must(template.New("opf").Funcs(epubFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="uid" prefix="rendition: http://www.idpf.org/vocab/rendition/#">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="uid">{{.ID}}</dc:identifier>
+    <dc:title>{{x .Title}}</dc:title>
+    <dc:language>{{x .Language}}</dc:language>
+    <meta property="dcterms:modified">{{.Modified}}</meta>
+{{- if .Meta.Series}}
+    <meta property="belongs-to-collection" id="series">{{x .Meta.Series}}</meta>
+    <meta refines="#series" property="collection-type">series</meta>
+{{- if not .Meta.Chapters}}
+    <meta refines="#series" property="group-position">{{x .Meta.Number}}</meta>
+{{- end}}
+{{- end}}
+    <meta property="rendition:layout">pre-paginated</meta>
+    <meta property="rendition:orientation">auto</meta>
+    <meta property="rendition:spread">landscape</meta>
+    <meta name="cover" content="image-0001"/>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+{{- range .Pages}}
+    <item id="image-{{printf "%04d" .N}}" href="{{.Image}}" media-type="{{.MediaType}}"{{if eq .N 1}} properties="cover-image"{{end}}/>
+    <item id="{{.ID}}" href="{{.Href}}" media-type="application/xhtml+xml"/>
+{{- end}}
+  </manifest>
+  <spine page-progression-direction="{{.Direction}}">
+{{- range .Pages}}
+    <itemref idref="{{.ID}}"/>
+{{- end}}
+  </spine>
+</package>
+`))
+
+var epubNavTemplate = template.Must(template.New("nav").Funcs(epubFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="{{x .Language}}" xml:lang="{{x .Language}}">
+<head>
+  <title>{{x .Title}}</title>
+</head>
+<body>
+  <nav epub:type="toc" id="toc">
+    <ol>
+{{- range .Nav}}
+      <li><a href="{{x .Href}}">{{x .Label}}</a></li>
+{{- end}}
+    </ol>
+  </nav>
+</body>
+</html>
+`))
+
+var epubPageTemplate = template.Must(template.New("page").Funcs(epubFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head>
+  <title>Page {{.N}}</title>
+  <meta name="viewport" content="width={{.Width}}, height={{.Height}}"/>
+  <style>html, body { margin: 0; padding: 0; } img { display: block; width: {{.Width}}px; height: {{.Height}}px; }</style>
+</head>
+<body>
+  <img src="../{{.Image}}" alt="Page {{.N}}"/>
+</body>
+</html>
+`))
@@ -0,0 +1,177 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// epubEntries opens the epub at path and returns its entries' contents by
+// name, along with the entries in order
+func epubEntries(t *testing.T, path string) (map[string]string, []*zip.File) {
+	t.Helper()
+
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("opening epub: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+
+	contents := map[string]string{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		contents[f.Name] = string(data)
+	}
+	return contents, r.File
+}
+
+func TestArchiveEPUBNoFiles(t *testing.T) {
+	err := ArchiveEPUB(filepath.Join(t.TempDir(), "empty.epub"), Metadata{}, []File{}, func(page, progress int) {})
+	if err == nil {
+		t.Fatal("expected an error when archiving zero files, got nil")
+	}
+}
+
+func TestArchiveEPUBBundle(t *testing.T) {
+	files := []File{
+		{Name: "Chapter 0001/000.png", Data: pngBytes(t, gradientImage(8, 12))},
+		{Name: "Chapter 0001/001.jpg", Data: jpegBytes(t, gradientImage(16, 12))},
+		{Name: "Chapter 0002/000.jpg", Data: []byte("not an image")},
+	}
+	meta := Metadata{
+		Series:    "Tom & Jerry",
+		Number:    "1-2",
+		Language:  "es",
+		Direction: DirectionRTL,
+		Chapters: []ChapterMetadata{
+			{Folder: "Chapter 0001", Number: 1, Title: "Start"},
+			{Folder: "Chapter 0002", Number: 2},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "bundle.epub")
+	if err := ArchiveEPUB(path, meta, files, func(page, progress int) {}); err != nil {
+		t.Fatalf("ArchiveEPUB: %v", err)
+	}
+	contents, entries := epubEntries(t, path)
+
+	if entries[0].Name != "mimetype" || entries[0].Method != zip.Store || contents["mimetype"] != "application/epub+zip" {
+		t.Errorf("expected an uncompressed mimetype first, got %q (method %d)", entries[0].Name, entries[0].Method)
+	}
+
+	opf := contents["OEBPS/content.opf"]
+	for _, want := range []string{
+		"<dc:title>Tom &amp; Jerry 1-2</dc:title>",
+		"<dc:language>es</dc:language>",
+		`<meta property="rendition:layout">pre-paginated</meta>`,
+		`<spine page-progression-direction="rtl">`,
+		`href="images/0001.png" media-type="image/png" properties="cover-image"`,
+	} {
+		if !strings.Contains(opf, want) {
+			t.Errorf("expected the package document to contain %q, got:\n%s", want, opf)
+		}
+	}
+
+	nav := contents["OEBPS/nav.xhtml"]
+	for _, want := range []string{
+		`<li><a href="pages/0001.xhtml">Chapter 1: Start</a></li>`,
+		`<li><a href="pages/0003.xhtml">Chapter 2</a></li>`,
+	} {
+		if !strings.Contains(nav, want) {
+			t.Errorf("expected the nav document to contain %q, got:\n%s", want, nav)
+		}
+	}
+	if n := strings.Count(nav, "<li>"); n != 2 {
+		t.Errorf("expected a nav entry per chapter, got %d", n)
+	}
+
+	cases := map[string]string{
+		"OEBPS/pages/0001.xhtml": `content="width=8, height=12"`,
+		"OEBPS/pages/0002.xhtml": `content="width=16, height=12"`,
+		// undecodable pages still get a page, at the default size
+		"OEBPS/pages/0003.xhtml": `content="width=1000, height=1500"`,
+	}
+	for name, want := range cases {
+		if !strings.Contains(contents[name], want) {
+			t.Errorf("expected %s to have the viewport %s, got:\n%s", name, want, contents[name])
+		}
+	}
+}
+
+func TestArchiveEPUBSingleChapter(t *testing.T) {
+	meta := Metadata{Series: "Series", Number: "3", Title: "Third", Direction: DirectionLTR}
+	path := filepath.Join(t.TempDir(), "single.epub")
+	if err := ArchiveEPUB(path, meta, []File{{Name: "000.jpg", Data: []byte("page")}}, func(page, progress int) {}); err != nil {
+		t.Fatalf("ArchiveEPUB: %v", err)
+	}
+	contents, _ := epubEntries(t, path)
+
+	opf := contents["OEBPS/content.opf"]
+	for _, want := range []string{
+		"<dc:title>Series 3 - Third</dc:title>",
+		"<dc:language>und</dc:language>",
+		`<meta refines="#series" property="group-position">3</meta>`,
+		`<spine page-progression-direction="ltr">`,
+	} {
+		if !strings.Contains(opf, want) {
+			t.Errorf("expected the package document to contain %q, got:\n%s", want, opf)
+		}
+	}
+	if nav := contents["OEBPS/nav.xhtml"]; strings.Count(nav, "<li>") != 1 || !strings.Contains(nav, ">Third</a>") {
+		t.Errorf("expected a single nav entry for the chapter, got:\n%s", nav)
+	}
+}
+
+func TestPackEPUBName(t *testing.T) {
+	name, err := packTwice(t, t.TempDir(), FormatEPUB, FilenameTemplateDefault, OnExistsVersion)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if name != "Series 1 - One v2.epub" {
+		t.Errorf("expected the epub extension, got %q", name)
+	}
+
+	parser, _ := NewFilenameParser(FilenameTemplateDefault)
+	if parts, ok := parser.Parse(name); !ok || parts.Title != "One" || parts.Version != 2 {
+		t.Errorf("expected the epub name to parse, got %+v (%v)", parts, ok)
+	}
+}
+
+func TestArchiveEPUBReproducible(t *testing.T) {
+	dir := t.TempDir()
+	meta := Metadata{Series: "Series", Number: "1"}
+	files := []File{{Name: "000.jpg", Data: []byte("page 0")}, {Name: "001.jpg", Data: []byte("page 1")}}
+
+	archive := func(name string) []byte {
+		path := filepath.Join(dir, name)
+		if err := ArchiveEPUB(path, meta, files, func(page, progress int) {}); err != nil {
+			t.Fatalf("ArchiveEPUB: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	if !bytes.Equal(archive("first.epub"), archive("second.epub")) {
+		t.Error("expected the same pages to make the same book")
+	}
+
+	_, entries := epubEntries(t, filepath.Join(dir, "first.epub"))
+	for _, f := range entries {
+		if !f.Modified.Equal(entryModified) {
+			t.Errorf("expected %s to have the fixed time, got %s", f.Name, f.Modified)
+		}
+	}
+}
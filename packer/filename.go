@@ -5,6 +5,7 @@ package packer
 import (
 	"bytes"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"text/template"
@@ -20,6 +21,9 @@ type FilenameTemplateParts struct {
 	Number string
 	// Title represents the chapter title (e.g. "The Beginning")
 	Title string
+	// Volume represents the volume the chapter (or bundle) belongs to (e.g.
+	// "3"), empty when the site doesn't tell
+	Volume string
 	// Version placeholder appended to the title in case of duplicate filenames (e.g. "3")
 	Version int
 }
@@ -46,6 +50,7 @@ func NewChapterFileTemplateParts(title string, chapter *grabber.Chapter) Filenam
 		Series: SanitizeFilename(title),
 		Number: strings.Replace(fmt.Sprintf("%.1f", chapter.GetNumber()), ".0", "", 1),
 		Title:  SanitizeFilename(chapter.GetTitle()),
+		Volume: SanitizeFilename(chapter.GetVolume()),
 	}
 }
 
@@ -80,3 +85,109 @@ func SanitizeFilename(filename string) string {
 
 	return sanitized
 }
+
+// placeholders stand in for the template fields when rendering a template to
+// reverse-parse it; they can't appear in a template's own text
+const (
+	placeholderSeries  = "\x00series\x00"
+	placeholderNumber  = "\x00number\x00"
+	placeholderTitle   = "\x00title\x00"
+	placeholderVolume  = "\x00volume\x00"
+	placeholderVersion = 987654321
+)
+
+// FilenameParser reverse-parses the file names built by a filename template,
+// recovering the parts they were built from
+type FilenameParser struct {
+	// patterns are tried in order: with the version rendered, then without
+	patterns []*regexp.Regexp
+}
+
+// NewFilenameParser returns a parser for the file names built by templ. The
+// template is rendered with placeholders for every field, turning it into a
+// pattern; it's rendered twice (version 1 and above), so conditionals like the
+// default template's " vN" suffix are recognised both with and without it.
+func NewFilenameParser(templ string) (*FilenameParser, error) {
+	p := &FilenameParser{}
+	for _, version := range []int{placeholderVersion, 1} {
+		rendered, err := NewFilenameFromTemplate(templ, FilenameTemplateParts{
+			Series:  placeholderSeries,
+			Number:  placeholderNumber,
+			Title:   placeholderTitle,
+			Volume:  placeholderVolume,
+			Version: version,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		re, err := regexp.Compile("^" + filenamePattern(rendered) + "$")
+		if err != nil {
+			return nil, err
+		}
+		p.patterns = append(p.patterns, re)
+	}
+
+	return p, nil
+}
+
+// filenamePattern turns a template rendered with placeholders into a regular
+// expression capturing each field. A field used more than once is only
+// captured the first time (Go's regexp has no backreferences).
+func filenamePattern(rendered string) string {
+	fields := []struct {
+		placeholder string
+		name        string
+		pattern     string
+	}{
+		{placeholderSeries, "Series", `.+?`},
+		// single chapters ("10", "10.5") as well as bundle ranges ("1-8,10")
+		{placeholderNumber, "Number", `\d[\d.,\-]*`},
+		{placeholderTitle, "Title", `.*?`},
+		{placeholderVolume, "Volume", `.*?`},
+		{strconv.Itoa(placeholderVersion), "Version", `\d+`},
+	}
+
+	pattern := regexp.QuoteMeta(rendered)
+	for _, f := range fields {
+		placeholder := regexp.QuoteMeta(f.placeholder)
+		if i := strings.Index(pattern, placeholder); i >= 0 {
+			pattern = pattern[:i] + "(?P<" + f.name + ">" + f.pattern + ")" + pattern[i+len(placeholder):]
+		}
+		pattern = strings.ReplaceAll(pattern, placeholder, "(?:"+f.pattern+")")
+	}
+
+	return pattern
+}
+
+// Parse returns the parts name was built from (without its format's extension,
+// if any), or false if name doesn't match the template. Parts the template
+// doesn't use are left empty, and Version is 1 when the name has none.
+func (p *FilenameParser) Parse(name string) (FilenameTemplateParts, bool) {
+	name = name[:len(name)-len(Extension(FormatOf(name)))]
+	for _, re := range p.patterns {
+		match := re.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+
+		parts := FilenameTemplateParts{Version: 1}
+		for i, group := range re.SubexpNames() {
+			switch group {
+			case "Series":
+				parts.Series = match[i]
+			case "Number":
+				parts.Number = match[i]
+			case "Title":
+				parts.Title = match[i]
+			case "Volume":
+				parts.Volume = match[i]
+			case "Version":
+				parts.Version, _ = strconv.Atoi(match[i])
+			}
+		}
+		return parts, true
+	}
+
+	return FilenameTemplateParts{}, false
+}
@@ -0,0 +1,75 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import "testing"
+
+func TestFilenameParserDefaultTemplate(t *testing.T) {
+	p, err := NewFilenameParser(FilenameTemplateDefault)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cases := map[string]FilenameTemplateParts{
+		"One Piece 1 - Romance Dawn.cbz":  {Series: "One Piece", Number: "1", Title: "Romance Dawn", Version: 1},
+		"One Piece 10.5 - Extra v3.cbz":   {Series: "One Piece", Number: "10.5", Title: "Extra", Version: 3},
+		"Kaiju No. 8 12 - Kafka - Hibino": {Series: "Kaiju No. 8", Number: "12", Title: "Kafka - Hibino", Version: 1},
+		"One Piece 1-8,10 - bundle.cbz":   {Series: "One Piece", Number: "1-8,10", Title: "bundle", Version: 1},
+		"One Piece 3 - .cbz":              {Series: "One Piece", Number: "3", Title: "", Version: 1},
+	}
+	for name, want := range cases {
+		got, ok := p.Parse(name)
+		if !ok || got != want {
+			t.Errorf("%q: expected %+v, got %+v (matched: %v)", name, want, got, ok)
+		}
+	}
+
+	if _, ok := p.Parse("cover.jpg"); ok {
+		t.Error("expected a name not following the template not to match")
+	}
+}
+
+func TestFilenameParserCustomTemplate(t *testing.T) {
+	p, err := NewFilenameParser("[{{.Number}}] {{.Title}} ({{.Series}}, {{.Number}})")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got, ok := p.Parse("[7] Seven (Series, 7)")
+	want := FilenameTemplateParts{Series: "Series", Number: "7", Title: "Seven", Version: 1}
+	if !ok || got != want {
+		t.Errorf("expected %+v, got %+v (matched: %v)", want, got, ok)
+	}
+}
+
+// TestFilenameParserRoundTrip ensures names built by NewFilenameFromTemplate
+// parse back into the parts they were built from
+func TestFilenameParserRoundTrip(t *testing.T) {
+	p, err := NewFilenameParser(FilenameTemplateDefault)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, parts := range []FilenameTemplateParts{
+		{Series: "Dr. Stone", Number: "100", Title: "Z=100", Version: 1},
+		{Series: "Dr. Stone", Number: "100", Title: "Z=100", Version: 2},
+	} {
+		name, _ := NewFilenameFromTemplate(FilenameTemplateDefault, parts)
+		if got, ok := p.Parse(name + ".cbz"); !ok || got != parts {
+			t.Errorf("%q: expected %+v back, got %+v", name, parts, got)
+		}
+	}
+}
+
+func TestFilenameParserVolumeTemplate(t *testing.T) {
+	p, err := NewFilenameParser("{{.Series}} Vol. {{.Volume}} ({{.Number}})")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got, ok := p.Parse("Series Vol. 3 (12-18).cbz")
+	want := FilenameTemplateParts{Series: "Series", Number: "12-18", Volume: "3", Version: 1}
+	if !ok || got != want {
+		t.Errorf("expected %+v, got %+v (matched: %v)", want, got, ok)
+	}
+}
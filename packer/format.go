@@ -0,0 +1,123 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Supported output formats (see grabber.Settings.Format)
+const (
+	FormatCBZ  = "cbz"
+	FormatCBT  = "cbt"
+	FormatEPUB = "epub"
+	FormatPDF  = "pdf"
+	FormatRaw  = "raw"
+)
+
+// Format is an output format: what it's called, how its files are named and
+// how they're written
+type Format struct {
+	// Name is the format's name, as given to --format
+	Name string
+	// Extension is the extension of its files, empty for a folder
+	Extension string
+	// Description tells what the format is, for the help
+	Description string
+	// newWriter creates path (which mustn't exist) to write its pages in
+	newWriter func(path string) (pageWriter, error)
+	// countPages returns the number of pages saved in path
+	countPages func(path string) (int64, error)
+}
+
+// Formats lists every supported output format, CBZ (the default) first
+var Formats = []Format{
+	{
+		Name:        FormatCBZ,
+		Extension:   ".cbz",
+		Description: "a zip archive of the images",
+		newWriter:   func(path string) (pageWriter, error) { return newCBZWriter(path) },
+		countPages:  countZipPages,
+	},
+	{
+		Name:        FormatCBT,
+		Extension:   ".cbt",
+		Description: "a tar archive of the images",
+		newWriter:   func(path string) (pageWriter, error) { return newCBTWriter(path) },
+		countPages:  countTarPages,
+	},
+	{
+		Name:        FormatEPUB,
+		Extension:   ".epub",
+		Description: "fixed-layout, for Kobo and Apple Books",
+		newWriter:   func(path string) (pageWriter, error) { return newEPUBWriter(path) },
+		countPages:  countEPUBPages,
+	},
+	{
+		Name:        FormatPDF,
+		Extension:   ".pdf",
+		Description: "a page per image",
+		newWriter:   func(path string) (pageWriter, error) { return newPDFWriter(path) },
+		countPages:  countPDFPages,
+	},
+	{
+		Name:        FormatRaw,
+		Description: "a folder with the images",
+		newWriter:   func(path string) (pageWriter, error) { return newRawWriter(path) },
+		countPages:  countRawPages,
+	},
+}
+
+// LookupFormat returns the format called name
+func LookupFormat(name string) (Format, bool) {
+	for _, format := range Formats {
+		if format.Name == name {
+			return format, true
+		}
+	}
+
+	return Format{}, false
+}
+
+// FormatNames returns the names of every supported output format
+func FormatNames() []string {
+	names := make([]string, len(Formats))
+	for i, format := range Formats {
+		names[i] = format.Name
+	}
+
+	return names
+}
+
+// FormatsHelp describes every supported output format, for the help of
+// --format
+func FormatsHelp() string {
+	help := make([]string, len(Formats))
+	for i, format := range Formats {
+		help[i] = format.Name + " (" + format.Description + ")"
+	}
+
+	return strings.Join(help[:len(help)-1], ", ") + " or " + help[len(help)-1]
+}
+
+// Extension returns the extension of the files saved in format, empty for the
+// formats saving folders (raw)
+func Extension(format string) string {
+	f, _ := LookupFormat(format)
+
+	return f.Extension
+}
+
+// FormatOf returns the format a saved file (or folder) name is in, by its
+// extension (in any case), FormatRaw for a name without a format's one
+func FormatOf(name string) string {
+	ext := filepath.Ext(name)
+	for _, format := range Formats {
+		if format.Extension != "" && strings.EqualFold(ext, format.Extension) {
+			return format.Name
+		}
+	}
+
+	return FormatRaw
+}
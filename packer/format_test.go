@@ -0,0 +1,51 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFormats(t *testing.T) {
+	if Formats[0].Name != FormatCBZ {
+		t.Errorf("expected cbz, the default, first")
+	}
+	for _, format := range Formats {
+		if f, ok := LookupFormat(format.Name); !ok || f.Name != format.Name {
+			t.Errorf("expected to look %s up", format.Name)
+		}
+		if format.newWriter == nil || format.Description == "" {
+			t.Errorf("expected %s to have a writer and a description", format.Name)
+		}
+		if got := FormatOf("Series 1" + format.Extension); got != format.Name {
+			t.Errorf("FormatOf(%q) = %s, want %s", "Series 1"+format.Extension, got, format.Name)
+		}
+		if format.countPages == nil {
+			t.Errorf("expected %s to count its pages", format.Name)
+		}
+	}
+	if _, ok := LookupFormat("cbr"); ok {
+		t.Error("expected unknown formats not to be found")
+	}
+	if Extension(FormatCBT) != ".cbt" || Extension(FormatRaw) != "" {
+		t.Errorf("unexpected extensions %q, %q", Extension(FormatCBT), Extension(FormatRaw))
+	}
+}
+
+func TestCountPages(t *testing.T) {
+	dir := t.TempDir()
+	page := jpegBytes(t, gradientImage(16, 16))
+	files := []File{{Name: "000.jpg", Data: page}, {Name: "001.jpg", Data: page}, {Name: "002.jpg", Data: page}}
+	meta := Metadata{Series: "Series", Number: "1"}
+
+	for _, format := range Formats {
+		path := filepath.Join(dir, "Series 1"+format.Extension)
+		if err := writePages(format.Name, path, meta, files, func(page, progress int) {}); err != nil {
+			t.Fatalf("%s: %s", format.Name, err)
+		}
+		if n, err := CountPages(path); err != nil || n != int64(len(files)) {
+			t.Errorf("%s: CountPages = %d, %v; want %d", format.Name, n, err, len(files))
+		}
+	}
+}
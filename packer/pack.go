@@ -3,67 +3,244 @@
 package packer
 
 import (
+	"archive/zip"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/elboletaire/manga-downloader/downloader"
 	"github.com/elboletaire/manga-downloader/grabber"
+	"github.com/elboletaire/manga-downloader/packer/pageopts"
 	"github.com/fatih/color"
 )
 
-// Supported output formats (see grabber.Settings.Format)
+// How bundles are split (see grabber.Settings.BundleBy)
 const (
-	FormatCBZ = "cbz"
-	FormatRaw = "raw"
+	// BundleByRange bundles every chapter downloaded into a single file
+	BundleByRange = "range"
+	// BundleByVolume bundles each volume's chapters into a file of its own
+	BundleByVolume = "volume"
+)
+
+// BundleByModes lists every supported way of splitting bundles
+var BundleByModes = []string{BundleByRange, BundleByVolume}
+
+// Policies for an output file that already exists (see grabber.Settings.OnExists)
+const (
+	// OnExistsVersion saves under the next free " vN" name
+	OnExistsVersion = "version"
+	// OnExistsSkip keeps the existing file and returns ErrSkipped
+	OnExistsSkip = "skip"
+	// OnExistsOverwrite replaces the existing file
+	OnExistsOverwrite = "overwrite"
+	// OnExistsFail returns an ErrExists error
+	OnExistsFail = "fail"
+)
+
+// OnExistsPolicies lists every supported OnExists policy
+var OnExistsPolicies = []string{OnExistsVersion, OnExistsSkip, OnExistsOverwrite, OnExistsFail}
+
+var (
+	// ErrSkipped is returned, along with the existing file's name, when the
+	// output file already exists and the policy is OnExistsSkip
+	ErrSkipped = errors.New("file already exists, skipped")
+	// ErrExists is wrapped by the error returned when the output file already
+	// exists and the policy is OnExistsFail
+	ErrExists = errors.New("file already exists")
 )
 
 // DownloadedChapter represents a downloaded chapter (a Chapter + Files)
 type DownloadedChapter struct {
 	*grabber.Chapter
 	Files []*downloader.File
+	// Spool holds the chapter's pages on disk until it's packed (may be nil)
+	Spool *downloader.Spool
+	// Replaces is the file (relative to the output dir) of a previous download
+	// of the chapter, to be replaced by this one: the site changed the chapter
+	// since (see ledger.Entry.Outdated)
+	Replaces string
 }
 
-// PackSingle packs a single downloaded chapter
-func PackSingle(outputdir string, s grabber.Site, chapter *DownloadedChapter, progress func(page, progress int)) (string, error) {
-	title, _ := s.FetchTitle()
-	return pack(outputdir, s.GetFormat(), s.GetFilenameTemplate(), title, NewChapterFileTemplateParts(title, chapter.Chapter), namePages(chapter.Files, s.GetConvertImages()), progress)
+// Metadata describes the chapter (or bundle of chapters) being packed, for
+// the formats embedding it (cbz, epub, pdf)
+type Metadata struct {
+	// Series is the series title
+	Series string
+	// Number is the chapter number, or the range of a bundle
+	Number string
+	// Volume is the volume of the chapter (or bundle), if known
+	Volume string
+	// Title is the chapter title, empty for bundles
+	Title string
+	// Language is the chapters' language, if known
+	Language string
+	// Web is the series URL
+	Web string
+	// Direction is the reading direction (DirectionRTL or DirectionLTR)
+	Direction string
+	// Chapters are the chapters of a bundle, in order (empty for a single one)
+	Chapters []ChapterMetadata
+}
+
+// ChapterMetadata describes a chapter of a bundle
+type ChapterMetadata struct {
+	// Folder is the folder holding the chapter's pages in the bundle
+	Folder string
+	// Number is the chapter number
+	Number float64
+	// Title is the chapter title
+	Title string
 }
 
-// PackBundle packs a bundle of downloaded chapters, grouping each chapter's
-// pages into its own folder inside the archive (Chapter 0001/000.jpg, ...)
-// so chapter boundaries survive bundling instead of a single flat renumbering.
-func PackBundle(outputdir string, s grabber.Site, chapters []*DownloadedChapter, rng string, progress func(page, progress int)) (string, error) {
+// PackSingle packs a single downloaded chapter, its pages reworked as pages
+// says, removing its page spool once it's saved (or skipped, its file being
+// there already).
+//
+// A chapter replacing a previous download is saved over its file, whatever
+// the filename template and the OnExists policy say: a fresh name could land
+// on another release's file, or leave the old one next to the new. Only when
+// the old file is of another format it's saved as usual, and the old removed.
+func PackSingle(outputdir string, s grabber.Site, pages pageopts.Options, chapter *DownloadedChapter, progress func(page, progress int)) (string, error) {
+	reworked, err := newPageTransforms(s, pages).apply(chapter.Files)
+	if err != nil {
+		return "", fmt.Errorf("reworking the pages of %s: %w", chapter.GetTitle(), err)
+	}
+	files := namePages(reworked, s.GetConvertImages())
+	progress = perSourcePage(progress, len(chapter.Files), len(files))
 	title, _ := s.FetchTitle()
-	files := []File{}
-	// Several chapters can share a number (a site re-releasing the same chapter,
-	// or two genuinely distinct chapters both numbered, e.g. a pair of
-	// season-finale entries). Each chapter gets its own "Chapter 0001/" folder
-	// so chapter boundaries survive bundling, so repeated numbers are
-	// disambiguated with a " (2)", " (3)" suffix instead of silently merging
-	// both chapters' pages into one folder (which would lose the first one's).
-	usedFolders := map[string]int{}
-	for _, chapter := range chapters {
-		base := SanitizeFilename(fmt.Sprintf("Chapter %s", paddedChapterNumber(chapter.GetNumber())))
-		folder := base
-		if n := usedFolders[base]; n > 0 {
-			folder = fmt.Sprintf("%s (%d)", base, n+1)
+	parts := NewChapterFileTemplateParts(title, chapter.Chapter)
+	meta := Metadata{
+		Series:    title,
+		Number:    parts.Number,
+		Title:     chapter.GetTitle(),
+		Volume:    chapter.GetVolume(),
+		Language:  chapter.Language,
+		Web:       s.GetURL(),
+		Direction: s.GetReadingDirection(),
+	}
+	if chapter.Replaces != "" && FormatOf(chapter.Replaces) == s.GetFormat() {
+		err := packOver(outputdir, s.GetFormat(), chapter.Replaces, meta, files, progress)
+		if err == nil {
+			chapter.Spool.Remove()
+		}
+		return chapter.Replaces, err
+	}
+
+	name, err := pack(outputdir, s.GetFormat(), s.GetFilenameTemplate(), s.GetOnExists(), meta, parts, files, progress)
+	if err == nil || errors.Is(err, ErrSkipped) {
+		chapter.Spool.Remove()
+	}
+	if err == nil && chapter.Replaces != "" {
+		os.RemoveAll(filepath.Join(outputdir, chapter.Replaces))
+	}
+
+	return name, err
+}
+
+// BackupDir is the folder, inside the output dir, keeping the files replaced
+// by upgraded chapters (see Backup)
+const BackupDir = ".backup"
+
+// Backup keeps a copy of the file name (relative to outputdir) in BackupDir,
+// replacing any previous backup of it. Files are hard linked where possible,
+// so backing up costs no space until the original is replaced.
+func Backup(outputdir, name string) error {
+	src := filepath.Join(outputdir, name)
+	dst := filepath.Join(outputdir, BackupDir, name)
+	if err := os.RemoveAll(dst); err != nil {
+		return err
+	}
+
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
 		}
-		usedFolders[base]++
 
-		for _, page := range namePages(chapter.Files, s.GetConvertImages()) {
-			files = append(files, File{
-				Name: fmt.Sprintf("%s/%s", folder, page.Name),
-				Data: page.Data,
-			})
+		return linkOrCopy(path, target)
+	})
+}
+
+// linkOrCopy hard links src as dst, copying it where links aren't supported
+// (e.g. FAT formatted drives)
+func linkOrCopy(src, dst string) error {
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// CountPages returns the number of pages saved in path, in the format its
+// name tells (see FormatOf): read from an archive's index or a PDF's page
+// tree, without decompressing anything, or the files in a raw folder
+func CountPages(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	format, _ := LookupFormat(FormatOf(path))
+	if info.IsDir() {
+		format, _ = LookupFormat(FormatRaw)
+	}
+
+	return format.countPages(path)
+}
+
+// countRawPages returns the number of files in the raw folder at path
+func countRawPages(path string) (int64, error) {
+	var pages int64
+	err := filepath.WalkDir(path, func(_ string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			pages++
+		}
+		return err
+	})
+
+	return pages, err
+}
+
+// countZipPages returns the number of pages in the CBZ archive at path: its
+// entries but its ComicInfo.xml, read from its central directory
+func countZipPages(path string) (int64, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	var pages int64
+	for _, f := range r.File {
+		if !f.FileInfo().IsDir() && f.Name != ComicInfoName {
+			pages++
 		}
 	}
 
-	return pack(outputdir, s.GetFormat(), s.GetFilenameTemplate(), title, FilenameTemplateParts{
-		Series: title,
-		Number: rng,
-		Title:  "bundle",
-	}, files, progress)
+	return pages, nil
 }
 
 // namePages names a chapter's pages sequentially (000.jpg, 001.png, ...),
@@ -96,32 +273,166 @@ func namePages(pages []*downloader.File, formats grabber.ConvertFormats) []File
 	return named
 }
 
-func pack(outputdir, format, template, title string, parts FilenameTemplateParts, files []File, progress func(page, progress int)) (string, error) {
+// placing serializes moving packed files into place, so two chapters packed
+// at once under the same name (e.g. duplicate releases of a chapter) can't
+// both see the name free and overwrite one another
+var placing sync.Mutex
+
+// pack saves files into outputdir, named after template, resolving a name
+// that's already taken according to the onExists policy. Everything is written
+// into a hidden staging directory inside outputdir first and only renamed into
+// place once complete, so an interrupted run never leaves a truncated archive
+// (or a half-filled raw folder) behind under the final name.
+//
+// With OnExistsSkip, the existing file's name is returned along with ErrSkipped.
+func pack(outputdir, format, template, onExists string, meta Metadata, parts FilenameTemplateParts, files []File, progress func(page, progress int)) (string, error) {
 	parts.Version = 1
+	name, err := packName(format, template, parts)
+	if err != nil {
+		return "", fmt.Errorf("- error creating filename for chapter %s: %s", meta.Series, err.Error())
+	}
+
+	// skipping or failing doesn't need to pack anything first
+	if onExists == OnExistsSkip || onExists == OnExistsFail {
+		if exists(filepath.Join(outputdir, name)) {
+			return collision(onExists, name)
+		}
+	}
 
+	staging, staged, err := stage(outputdir, format, name, meta, files, progress)
+	defer os.RemoveAll(staging)
+	if err != nil {
+		return "", fmt.Errorf("- error saving file %s: %s", name, err.Error())
+	}
+
+	return place(outputdir, format, template, onExists, meta.Series, name, parts, staging, staged)
+}
+
+// place moves staged (in staging, see stage) into outputdir as name, named
+// after template and parts as the onExists policy says when name is taken
+func place(outputdir, format, template, onExists, series, name string, parts FilenameTemplateParts, staging, staged string) (string, error) {
+	placing.Lock()
+	defer placing.Unlock()
+
+	var err error
 	for {
-		filename, err := NewFilenameFromTemplate(template, parts)
-		if err != nil {
-			return "", fmt.Errorf("- error creating filename for chapter %s: %s", title, err.Error())
+		target := filepath.Join(outputdir, name)
+		if !exists(target) {
+			err = os.Rename(staged, target)
+			break
 		}
 
-		var name string
-		if format == FormatRaw {
-			name = filename
-			err = SaveRaw(filepath.Join(outputdir, name), files, progress)
-		} else {
-			name = filename + ".cbz"
-			err = ArchiveCBZ(filepath.Join(outputdir, name), files, progress)
+		if onExists != OnExistsVersion {
+			if onExists == OnExistsOverwrite {
+				err = replace(staged, target, staging)
+				break
+			}
+			return collision(onExists, name)
 		}
 
-		if os.IsExist(err) {
-			parts.Version++
-			continue
+		parts.Version++
+		var next string
+		if next, err = packName(format, template, parts); err != nil {
+			return "", fmt.Errorf("- error creating filename for chapter %s: %s", series, err.Error())
 		}
-		if err != nil {
-			return "", fmt.Errorf("- error saving file %s: %s", name, err.Error())
+		if next == name {
+			// the template doesn't use {{.Version}}, bumping it would loop forever
+			return "", fmt.Errorf("- error saving file %s: %w (and the filename template has no {{.Version}} to tell them apart)", name, ErrExists)
 		}
+		name = next
+	}
+	if err != nil {
+		return "", fmt.Errorf("- error saving file %s: %s", name, err.Error())
+	}
+
+	return name, nil
+}
+
+// packOver saves files as name (relative to outputdir) whatever the template
+// says, replacing the file there. Like pack, it only touches name once
+// everything is written.
+func packOver(outputdir, format, name string, meta Metadata, files []File, progress func(page, progress int)) error {
+	staging, staged, err := stage(outputdir, format, filepath.Base(name), meta, files, progress)
+	defer os.RemoveAll(staging)
+	if err != nil {
+		return fmt.Errorf("- error saving file %s: %s", name, err.Error())
+	}
+
+	placing.Lock()
+	defer placing.Unlock()
+
+	target := filepath.Join(outputdir, name)
+	if exists(target) {
+		err = replace(staged, target, staging)
+	} else {
+		err = os.Rename(staged, target)
+	}
+	if err != nil {
+		return fmt.Errorf("- error saving file %s: %s", name, err.Error())
+	}
+
+	return nil
+}
+
+// stage writes files as name into a new hidden staging directory inside
+// outputdir (see newStaging), returning both. The staging directory is returned even on error,
+// for the caller to remove.
+func stage(outputdir, format, name string, meta Metadata, files []File, progress func(page, progress int)) (staging, staged string, err error) {
+	if staging, err = newStaging(outputdir); err != nil {
+		return "", "", err
+	}
+
+	staged = filepath.Join(staging, name)
+	err = writePages(format, staged, meta, files, progress)
 
-		return name, nil
+	return staging, staged, err
+}
+
+// newStaging creates a hidden staging directory inside outputdir, to write
+// files in before they're moved into place
+func newStaging(outputdir string) (string, error) {
+	return os.MkdirTemp(outputdir, ".manga-downloader-*")
+}
+
+// packName returns the name of the file (or folder, for raw) to pack into
+func packName(format, template string, parts FilenameTemplateParts) (string, error) {
+	filename, err := NewFilenameFromTemplate(template, parts)
+	if err != nil {
+		return "", err
 	}
+
+	return filename + Extension(format), nil
+}
+
+// collision returns the outcome of name being taken under the skip and fail
+// policies
+func collision(onExists, name string) (string, error) {
+	if onExists == OnExistsSkip {
+		return name, ErrSkipped
+	}
+
+	return "", fmt.Errorf("- error saving file %s: %w", name, ErrExists)
+}
+
+// replace moves staged over the existing target. A file is replaced atomically
+// by the rename itself; a raw folder can't be, so the old one is moved aside
+// into staging (removed along with it) right before the new one takes its place.
+func replace(staged, target, staging string) error {
+	info, err := os.Lstat(target)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		if err := os.Rename(target, filepath.Join(staging, ".replaced")); err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(staged, target)
+}
+
+// exists tells whether path exists (as anything, a dangling symlink included)
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
 }
@@ -0,0 +1,300 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elboletaire/manga-downloader/downloader"
+	"github.com/elboletaire/manga-downloader/grabber"
+	"github.com/elboletaire/manga-downloader/packer/pageopts"
+)
+
+// packTwice packs the same chapter into dir twice with the given policy,
+// returning the second outcome
+func packTwice(t *testing.T, dir, format, template, onExists string) (string, error) {
+	t.Helper()
+	noop := func(page, progress int) {}
+	parts := FilenameTemplateParts{Series: "Series", Number: "1", Title: "One"}
+	meta := Metadata{Series: "Series", Number: "1", Title: "One"}
+
+	if _, err := pack(dir, format, template, onExists, meta, parts, []File{{Name: "000.jpg", Data: []byte("first")}}, noop); err != nil {
+		t.Fatalf("unexpected error packing the first time: %s", err)
+	}
+
+	return pack(dir, format, template, onExists, meta, parts, []File{{Name: "000.jpg", Data: []byte("second")}}, noop)
+}
+
+// writeBundle bundles chapters with a BundleWriter, returning the parts saved
+func writeBundle(dir string, site grabber.Site, by, rng string, limits BundleLimits, chapters ...*DownloadedChapter) []BundlePart {
+	w := NewBundleWriter(dir, site, pageopts.Options{}, by, rng, limits, func(page, progress int) {})
+	for _, chapter := range chapters {
+		w.Add(chapter)
+	}
+
+	return w.Close()
+}
+
+// singlePart returns the outcome of a bundle expected to be saved into a
+// single file
+func singlePart(t *testing.T, parts []BundlePart) (string, error) {
+	t.Helper()
+	if len(parts) != 1 {
+		t.Fatalf("expected a single file, got %d", len(parts))
+	}
+	return parts[0].Name, parts[0].Err
+}
+
+// dirNames lists the entries of dir, hidden ones (the staging dirs) included
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestPackOnExistsVersion(t *testing.T) {
+	dir := t.TempDir()
+	name, err := packTwice(t, dir, FormatCBZ, FilenameTemplateDefault, OnExistsVersion)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if name != "Series 1 - One v2.cbz" {
+		t.Errorf("expected the second file to be versioned, got %q", name)
+	}
+	// nothing but both archives: the staging dirs are gone
+	if names := dirNames(t, dir); len(names) != 2 {
+		t.Errorf("expected exactly 2 files, got %v", names)
+	}
+}
+
+func TestPackOnExistsVersionWithoutVersionInTemplate(t *testing.T) {
+	_, err := packTwice(t, t.TempDir(), FormatCBZ, "{{.Series}} {{.Number}}", OnExistsVersion)
+	if !errors.Is(err, ErrExists) {
+		t.Errorf("expected an ErrExists error instead of looping forever, got: %v", err)
+	}
+}
+
+func TestPackOnExistsSkip(t *testing.T) {
+	dir := t.TempDir()
+	name, err := packTwice(t, dir, FormatRaw, FilenameTemplateDefault, OnExistsSkip)
+	if !errors.Is(err, ErrSkipped) {
+		t.Fatalf("expected ErrSkipped, got: %v", err)
+	}
+	if name != "Series 1 - One" {
+		t.Errorf("expected the existing name back, got %q", name)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, name, "000.jpg"))
+	if string(data) != "first" {
+		t.Errorf("expected the existing folder to be kept, got %q", data)
+	}
+}
+
+func TestPackOnExistsOverwrite(t *testing.T) {
+	for _, format := range []string{FormatCBZ, FormatRaw} {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			name, err := packTwice(t, dir, format, FilenameTemplateDefault, OnExistsOverwrite)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if format == FormatRaw {
+				data, _ := os.ReadFile(filepath.Join(dir, name, "000.jpg"))
+				if string(data) != "second" {
+					t.Errorf("expected the folder to be replaced, got %q", data)
+				}
+			} else if got := entryNames(t, filepath.Join(dir, name)); len(got) != 1 {
+				t.Errorf("expected a single entry in the replaced archive, got %v", got)
+			}
+			if names := dirNames(t, dir); len(names) != 1 {
+				t.Errorf("expected only the replaced file, got %v", names)
+			}
+		})
+	}
+}
+
+func TestPackOnExistsFail(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := packTwice(t, dir, FormatCBZ, FilenameTemplateDefault, OnExistsFail); !errors.Is(err, ErrExists) {
+		t.Errorf("expected an ErrExists error, got: %v", err)
+	}
+	if names := dirNames(t, dir); len(names) != 1 {
+		t.Errorf("expected the existing file alone, got %v", names)
+	}
+}
+
+func TestPackSingleReplaces(t *testing.T) {
+	dir := t.TempDir()
+	noop := func(page, progress int) {}
+	// an old download saved under a name the template wouldn't give it now
+	old := "Old name v2.cbz"
+	if err := ArchiveCBZ(filepath.Join(dir, old), Metadata{}, []File{{Name: "000.jpg", Data: []byte("old")}}, noop); err != nil {
+		t.Fatal(err)
+	}
+	if err := Backup(dir, old); err != nil {
+		t.Fatalf("unexpected error backing up: %s", err)
+	}
+
+	site := &fakeSite{title: "Series", template: FilenameTemplateDefault}
+	chapter := &DownloadedChapter{
+		Chapter:  &grabber.Chapter{Number: 1, Title: "One"},
+		Files:    []*downloader.File{{Data: []byte("new-1")}, {Data: []byte("new-2")}},
+		Replaces: old,
+	}
+	name, err := PackSingle(dir, site, pageopts.Options{}, chapter, noop)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if name != old {
+		t.Errorf("expected the chapter saved over %q, got %q", old, name)
+	}
+	if got := entryNames(t, filepath.Join(dir, old)); len(got) != 2 {
+		t.Errorf("expected the new pages in the old file, got %v", got)
+	}
+	if got := entryNames(t, filepath.Join(dir, BackupDir, old)); len(got) != 1 {
+		t.Errorf("expected the backup to keep the old pages, got %v", got)
+	}
+	// the old file, its backup folder, and no leftover staging
+	if names := dirNames(t, dir); len(names) != 2 {
+		t.Errorf("expected the old file and the backup folder alone, got %v", names)
+	}
+}
+
+func TestBundleWriterByVolume(t *testing.T) {
+	dir := t.TempDir()
+	site := &fakeSite{title: "Series", template: "{{.Series}}{{with .Volume}} Vol. {{.}}{{end}} - {{.Title}} ({{.Number}})"}
+	chapter := func(number float64, volume string) *DownloadedChapter {
+		return &DownloadedChapter{Chapter: &grabber.Chapter{Number: number, Volume: volume}, Files: []*downloader.File{{Data: []byte("p")}}}
+	}
+	chapters := []*DownloadedChapter{
+		chapter(1, "1"), chapter(2, "1"), chapter(2.5, ""), chapter(3, "2"), chapter(4, ""), chapter(10, "2"), chapter(12.5, "2"),
+	}
+
+	parts := writeBundle(dir, site, BundleByVolume, "1-12.5", BundleLimits{}, chapters...)
+	// volumes in the order they start, the chapters without one last
+	want := []string{
+		"Series Vol. 1 - Volume 1 (1-2).cbz",
+		"Series Vol. 2 - Volume 2 (3-12.5).cbz",
+		"Series - No volume (2.5-4).cbz",
+	}
+	if len(parts) != len(want) {
+		t.Fatalf("expected %d parts, got %+v", len(want), parts)
+	}
+	for i, part := range parts {
+		if part.Err != nil || part.Name != want[i] {
+			t.Errorf("part %d: expected %q, got %q (%v)", i, want[i], part.Name, part.Err)
+		}
+	}
+	if len(parts[1].Chapters) != 3 || parts[2].Chapters[1].GetNumber() != 4 {
+		t.Errorf("expected every chapter in its volume's bundle")
+	}
+	if info := readComicInfo(t, filepath.Join(dir, parts[1].Name)); info.Volume != 2 || info.Number != "3-12.5" {
+		t.Errorf("expected the volume in ComicInfo.xml, got %+v", info)
+	}
+}
+
+func TestBundleWriterMatchesArchive(t *testing.T) {
+	dir := t.TempDir()
+	site := &fakeSite{title: "Series", template: "{{.Series}} {{.Number}}"}
+	jpg := jpegBytes(t, gradientImage(4, 6))
+	chapters := []*DownloadedChapter{
+		{Chapter: &grabber.Chapter{Number: 1, Title: "One", Language: "en"}, Files: []*downloader.File{{Data: jpg}, {Data: jpg}}},
+		{Chapter: &grabber.Chapter{Number: 2}, Files: []*downloader.File{{Data: jpg}}},
+	}
+
+	name, err := singlePart(t, writeBundle(dir, site, BundleByRange, "1-2", BundleLimits{}, chapters...))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, chapter := range chapters {
+		if chapter.Files != nil {
+			t.Errorf("expected chapter %g's pages dropped once written", chapter.GetNumber())
+		}
+	}
+
+	// the same bundle, archived in one go from every page at once
+	want := filepath.Join(t.TempDir(), "want.cbz")
+	meta := Metadata{
+		Series:    "Series",
+		Number:    "1-2",
+		Language:  "en",
+		Web:       site.GetURL(),
+		Direction: DirectionRTL,
+		Chapters: []ChapterMetadata{
+			{Folder: "Chapter 0001", Number: 1, Title: "One"},
+			{Folder: "Chapter 0002", Number: 2},
+		},
+	}
+	files := []File{
+		{Name: "Chapter 0001/000.jpg", Data: jpg},
+		{Name: "Chapter 0001/001.jpg", Data: jpg},
+		{Name: "Chapter 0002/000.jpg", Data: jpg},
+	}
+	if err := ArchiveCBZ(want, meta, files, func(page, progress int) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := os.ReadFile(filepath.Join(dir, name))
+	expected, _ := os.ReadFile(want)
+	if !bytes.Equal(got, expected) {
+		t.Errorf("expected the streamed bundle to match the archive of its pages")
+	}
+}
+
+func TestPackBundleLimits(t *testing.T) {
+	chapter := func(number float64, pages int) *DownloadedChapter {
+		files := []*downloader.File{}
+		for range pages {
+			files = append(files, &downloader.File{Data: []byte("0123456789")})
+		}
+		return &DownloadedChapter{Chapter: &grabber.Chapter{Number: number}, Files: files}
+	}
+	cases := []struct {
+		name   string
+		limits BundleLimits
+		want   []string
+	}{
+		{"no limits", BundleLimits{}, []string{"Series 1-4 - bundle.cbz"}},
+		{"pages", BundleLimits{Pages: 4}, []string{"Series 1-2 - bundle.cbz", "Series 2-3 - bundle.cbz", "Series 4 - bundle.cbz"}},
+		// a chapter over the limit on its own still gets a part
+		{"size", BundleLimits{Size: 25}, []string{"Series 1 - bundle.cbz", "Series 2 - bundle.cbz", "Series 2-3 - bundle.cbz", "Series 4 - bundle.cbz"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			site := &fakeSite{title: "Series", template: "{{.Series}} {{.Number}} - {{.Title}}"}
+			// two chapters numbered 2, to check their folders in each part
+			chapters := []*DownloadedChapter{chapter(1, 2), chapter(2, 2), chapter(2, 1), chapter(3, 1), chapter(4, 3)}
+
+			parts := writeBundle(dir, site, BundleByRange, "1-4", c.limits, chapters...)
+			if len(parts) != len(c.want) {
+				t.Fatalf("expected %d parts, got %+v", len(c.want), parts)
+			}
+			for i, part := range parts {
+				if part.Err != nil || part.Name != c.want[i] {
+					t.Errorf("part %d: expected %q, got %q (%v)", i, c.want[i], part.Name, part.Err)
+				}
+			}
+			// the folders are numbered within each part
+			if c.limits.Pages > 0 {
+				if names := entryNames(t, filepath.Join(dir, parts[1].Name)); names[0] != "Chapter 0002/000.jpg" {
+					t.Errorf("expected the second chapter 2 in a plain folder of its part, got %v", names)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package pageopts
+
+// Defaults of the --auto-crop-* flags
+const (
+	AutoCropToleranceDefault   = 24
+	AutoCropMinRetainedDefault = 0.5
+)
+
+// Crop is how the blank margins around scanned pages (the white or black
+// borders of the scanner bed) are cropped, so the page fills the screen
+type Crop struct {
+	// Auto crops every page's margins of a single colour
+	Auto bool
+	// Tolerance is how much (0-255) a margin pixel's channels can differ from
+	// the margin's colour, for scanner and JPEG noise not to count as content
+	Tolerance int
+	// MinRetained is the least part (0-1) of a page's width and height the
+	// crop can leave: a page that would lose more is mostly blank, and is kept
+	// whole
+	MinRetained float64
+}
+
+// Enabled reports whether c crops pages at all
+func (c Crop) Enabled() bool {
+	return c.Auto
+}
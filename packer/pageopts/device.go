@@ -0,0 +1,118 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package pageopts
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Page formats a Device re-encodes pages in
+const (
+	DeviceJPEG = "jpg"
+	DevicePNG  = "png"
+)
+
+const (
+	// DeviceNone leaves pages as the site served them (but for
+	// --convert-images)
+	DeviceNone = "none"
+	// DeviceCustom is a profile made of the --device-* flags alone
+	DeviceCustom = "custom"
+)
+
+// Device is an e-reader profile: the screen every page is reworked for before
+// packing, the way KCC (Kindle Comic Converter) does
+type Device struct {
+	// Width and Height are the screen size, in pixels. Bigger pages are
+	// scaled down to fit, keeping their aspect ratio; 0 leaves that side
+	// alone.
+	Width  int
+	Height int
+	// Grayscale turns pages gray, for e-ink screens: smaller files, and no
+	// dithering of colours the screen can't show anyway
+	Grayscale bool
+	// Quality is the JPEG quality pages are encoded at (1-100)
+	Quality int
+	// Format is the format pages are encoded in: DeviceJPEG or DevicePNG
+	Format string
+}
+
+// Enabled reports whether d reworks pages at all
+func (d Device) Enabled() bool {
+	return d != Device{}
+}
+
+// DeviceProfiles are the built-in device profiles, keyed by their --device
+// name. The sizes are the screens' own, in portrait.
+var DeviceProfiles = map[string]Device{
+	"kindle":            {Width: 1072, Height: 1448, Grayscale: true, Quality: 85, Format: DeviceJPEG},
+	"kindle-paperwhite": {Width: 1236, Height: 1648, Grayscale: true, Quality: 85, Format: DeviceJPEG},
+	"kindle-oasis":      {Width: 1264, Height: 1680, Grayscale: true, Quality: 85, Format: DeviceJPEG},
+	"kindle-scribe":     {Width: 1860, Height: 2480, Grayscale: true, Quality: 85, Format: DeviceJPEG},
+	"kobo-clara":        {Width: 1072, Height: 1448, Grayscale: true, Quality: 85, Format: DeviceJPEG},
+	"kobo-libra2":       {Width: 1264, Height: 1680, Grayscale: true, Quality: 85, Format: DeviceJPEG},
+	"kobo-libra-colour": {Width: 1264, Height: 1680, Quality: 90, Format: DeviceJPEG},
+	"kobo-sage":         {Width: 1440, Height: 1920, Grayscale: true, Quality: 85, Format: DeviceJPEG},
+	"kobo-elipsa":       {Width: 1404, Height: 1872, Grayscale: true, Quality: 85, Format: DeviceJPEG},
+	// the reMarkable shows 16 grays only, which PNG stores losslessly in
+	// less than a JPEG takes
+	"remarkable": {Width: 1404, Height: 1872, Grayscale: true, Format: DevicePNG},
+}
+
+// DeviceNames lists every --device value: DeviceNone, DeviceCustom and the
+// profiles, sorted
+func DeviceNames() []string {
+	names := []string{}
+	for name := range DeviceProfiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return append([]string{DeviceNone, DeviceCustom}, names...)
+}
+
+// ParseDevice returns the device of the --device flags: the profile called
+// name, with the width, height and quality overridden by the given ones when
+// set (non-zero), and turned gray when grayscale is. DeviceCustom starts from
+// a color JPEG profile with no size limit, so those are all there is to it.
+func ParseDevice(name string, width, height int, grayscale bool, quality int) (Device, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	custom := width != 0 || height != 0 || grayscale || quality != 0
+
+	var d Device
+	switch name {
+	case "", DeviceNone:
+		if custom {
+			return Device{}, fmt.Errorf("--device-width, --device-height, --device-grayscale and --device-quality tune a device profile, they only apply along with --device (%q for a profile of your own)", DeviceCustom)
+		}
+		return Device{}, nil
+	case DeviceCustom:
+		d = Device{Format: DeviceJPEG}
+	default:
+		var ok bool
+		if d, ok = DeviceProfiles[name]; !ok {
+			return Device{}, fmt.Errorf("invalid --device value %q, must be one of: %s", name, strings.Join(DeviceNames(), ", "))
+		}
+	}
+
+	if width < 0 || height < 0 {
+		return Device{}, fmt.Errorf("invalid device size %dx%d, must be positive", width, height)
+	}
+	if quality < 0 || quality > 100 {
+		return Device{}, fmt.Errorf("invalid --device-quality value %d, must be between 1 and 100", quality)
+	}
+	if width > 0 {
+		d.Width = width
+	}
+	if height > 0 {
+		d.Height = height
+	}
+	if quality > 0 {
+		d.Quality = quality
+	}
+	d.Grayscale = d.Grayscale || grayscale
+
+	return d, nil
+}
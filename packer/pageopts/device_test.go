@@ -0,0 +1,49 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package pageopts
+
+import "testing"
+
+func TestParseDevice(t *testing.T) {
+	cases := []struct {
+		name      string
+		width     int
+		height    int
+		grayscale bool
+		quality   int
+		want      Device
+		wantErr   bool
+	}{
+		{name: "none", want: Device{}},
+		{name: "", want: Device{}},
+		{name: "kobo-libra2", want: DeviceProfiles["kobo-libra2"]},
+		{name: "Kindle-Paperwhite", want: DeviceProfiles["kindle-paperwhite"]},
+		// flags override the profile's values
+		{name: "kobo-libra2", height: 1600, quality: 70, want: Device{Width: 1264, Height: 1600, Grayscale: true, Quality: 70, Format: DeviceJPEG}},
+		{name: "kobo-libra-colour", grayscale: true, want: Device{Width: 1264, Height: 1680, Grayscale: true, Quality: 90, Format: DeviceJPEG}},
+		{name: "custom", width: 800, grayscale: true, want: Device{Width: 800, Grayscale: true, Format: DeviceJPEG}},
+		{name: "custom", want: Device{Format: DeviceJPEG}},
+		{name: "kindle-fire", wantErr: true},
+		{name: "none", width: 800, wantErr: true},
+		{name: "custom", quality: 101, wantErr: true},
+		{name: "custom", height: -1, wantErr: true},
+	}
+
+	for _, c := range cases {
+		got, err := ParseDevice(c.name, c.width, c.height, c.grayscale, c.quality)
+		if (err != nil) != c.wantErr {
+			t.Errorf("ParseDevice(%q, %d, %d, %v, %d) error = %v, wantErr %v", c.name, c.width, c.height, c.grayscale, c.quality, err, c.wantErr)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("ParseDevice(%q, %d, %d, %v, %d) = %+v, want %+v", c.name, c.width, c.height, c.grayscale, c.quality, got, c.want)
+		}
+	}
+}
+
+func TestDeviceNames(t *testing.T) {
+	names := DeviceNames()
+	if names[0] != DeviceNone || names[1] != DeviceCustom || len(names) != len(DeviceProfiles)+2 {
+		t.Errorf("unexpected device names %v", names)
+	}
+}
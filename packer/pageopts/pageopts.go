@@ -0,0 +1,144 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+// Package pageopts holds the options reworking pages before they're packed:
+// the e-reader device profile, the slicing of long strips, what's done with
+// double-page spreads and the cropping of blank margins. The commands read
+// them from their flags with FromFlags, which validates them too (an Options
+// value is always a valid one), and hand them to the packer: the sites know
+// nothing of them.
+package pageopts
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// What's done with double-page spreads: the landscape pages, wider than tall
+const (
+	// SpreadsKeep leaves spreads as they are
+	SpreadsKeep = "keep"
+	// SpreadsSplit cuts spreads into their two pages, in reading order
+	SpreadsSplit = "split"
+	// SpreadsRotate turns spreads sideways, to fill a portrait screen
+	SpreadsRotate = "rotate"
+	// SpreadsSplitAndKeep keeps spreads, followed by their two pages
+	SpreadsSplitAndKeep = "split-and-keep"
+)
+
+// SpreadsModes lists every supported spreads mode
+var SpreadsModes = []string{SpreadsKeep, SpreadsSplit, SpreadsRotate, SpreadsSplitAndKeep}
+
+// Options are how pages are reworked before packing. The zero value leaves
+// them as the site served them.
+type Options struct {
+	// Device is the e-reader profile pages are reworked for
+	Device Device
+	// Split is how long strips are cut into pages
+	Split Split
+	// Spreads is what's done with double-page spreads (one of SpreadsModes,
+	// "" being SpreadsKeep), read in the reading direction
+	Spreads string
+	// Crop is how the margins around pages are cropped
+	Crop Crop
+}
+
+// Enabled reports whether o reworks pages at all
+func (o Options) Enabled() bool {
+	return o.Device.Enabled() || o.Split.Enabled() || o.ReworksSpreads() || o.Crop.Enabled()
+}
+
+// ReworksSpreads reports whether double-page spreads are reworked at all
+func (o Options) ReworksSpreads() bool {
+	return o.Spreads != "" && o.Spreads != SpreadsKeep
+}
+
+// AddFlags defines the flags FromFlags reads on flags
+func AddFlags(flags *pflag.FlagSet) {
+	flags.String("device", DeviceNone, "e-reader profile to resize, grayscale and re-encode pages for: "+strings.Join(DeviceNames(), ", "))
+	flags.Int("device-width", 0, "screen width to scale pages down to, overriding the --device profile's")
+	flags.Int("device-height", 0, "screen height to scale pages down to, overriding the --device profile's")
+	flags.Bool("device-grayscale", false, "turn pages gray, whatever the --device profile says")
+	flags.Int("device-quality", 0, "jpeg quality (1-100) to encode pages at, overriding the --device profile's")
+	flags.Bool("split-tall", false, "slice pages taller than --split-height into several, at the gutters between panels (for webtoons)")
+	flags.Bool("restitch", false, "join each chapter's pages into a single strip and slice it again into pages of --split-height, at the gutters between panels (for webtoons cut at random heights)")
+	flags.Int("split-height", 0, "height to slice tall (or restitched) pages at, in pixels (default: the page width times the --device screen's aspect ratio, or 1.5)")
+	flags.String("spreads", SpreadsKeep, `what to do with double-page spreads: "keep", "split" (into two pages, in --reading-direction order), "rotate" or "split-and-keep" (the spread followed by its two pages)`)
+	flags.Bool("auto-crop", false, "crop the blank margins (of a single colour) around pages, so they fill the screen")
+	flags.Int("auto-crop-tolerance", AutoCropToleranceDefault, "how much (0-255) a margin's pixels can differ from its colour, for --auto-crop to still crop them")
+	flags.Float64("auto-crop-min-retained", AutoCropMinRetainedDefault, "least part (0-1) of a page's width and height --auto-crop can leave, mostly blank pages being kept whole")
+}
+
+// FromFlags reads the options from the flags defined by AddFlags, returning
+// an error when a flag can't be read or its value is invalid
+func FromFlags(flags *pflag.FlagSet) (Options, error) {
+	var (
+		o    Options
+		errs []error
+	)
+	// every flag is read before failing, for all the errors to be told at once
+	str := func(name string) string {
+		v, err := flags.GetString(name)
+		errs = append(errs, err)
+		return v
+	}
+	integer := func(name string) int {
+		v, err := flags.GetInt(name)
+		errs = append(errs, err)
+		return v
+	}
+	boolean := func(name string) bool {
+		v, err := flags.GetBool(name)
+		errs = append(errs, err)
+		return v
+	}
+	float := func(name string) float64 {
+		v, err := flags.GetFloat64(name)
+		errs = append(errs, err)
+		return v
+	}
+
+	device := str("device")
+	width, height := integer("device-width"), integer("device-height")
+	grayscale := boolean("device-grayscale")
+	quality := integer("device-quality")
+	o.Split = Split{Tall: boolean("split-tall"), Restitch: boolean("restitch"), Height: integer("split-height")}
+	o.Spreads = str("spreads")
+	o.Crop = Crop{Auto: boolean("auto-crop"), Tolerance: integer("auto-crop-tolerance"), MinRetained: float("auto-crop-min-retained")}
+	if err := errors.Join(errs...); err != nil {
+		return Options{}, err
+	}
+
+	var err error
+	if o.Device, err = ParseDevice(device, width, height, grayscale, quality); err != nil {
+		return Options{}, err
+	}
+
+	if o.Split.Height < 0 {
+		return Options{}, fmt.Errorf("invalid --split-height value %d, must be positive", o.Split.Height)
+	}
+	if o.Split.Height > 0 && !o.Split.Enabled() {
+		return Options{}, errors.New("--split-height sets where pages are sliced, it only applies along with --split-tall or --restitch")
+	}
+
+	if !slices.Contains(SpreadsModes, o.Spreads) {
+		return Options{}, fmt.Errorf("invalid --spreads value %q, must be one of: %s", o.Spreads, strings.Join(SpreadsModes, ", "))
+	}
+	if o.ReworksSpreads() && o.Split.Restitch {
+		// a restitched chapter is a single strip, with no pages left to tell
+		// spreads apart
+		return Options{}, errors.New("--spreads reworks double-page spreads, it can't be used along with --restitch, which joins the pages into a strip")
+	}
+
+	if o.Crop.Tolerance < 0 || o.Crop.Tolerance > 255 {
+		return Options{}, fmt.Errorf("invalid --auto-crop-tolerance value %d, must be between 0 and 255", o.Crop.Tolerance)
+	}
+	if o.Crop.MinRetained < 0 || o.Crop.MinRetained > 1 {
+		return Options{}, fmt.Errorf("invalid --auto-crop-min-retained value %g, must be between 0 and 1", o.Crop.MinRetained)
+	}
+
+	return o, nil
+}
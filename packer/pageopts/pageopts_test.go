@@ -0,0 +1,85 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package pageopts
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// parseFlags returns the options of the given command line arguments
+func parseFlags(t *testing.T, args ...string) (Options, error) {
+	t.Helper()
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		t.Fatalf("unexpected error parsing %v: %s", args, err)
+	}
+
+	return FromFlags(flags)
+}
+
+func TestFromFlags(t *testing.T) {
+	o, err := parseFlags(t)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if o.Enabled() {
+		t.Errorf("expected the defaults to leave pages alone, got %+v", o)
+	}
+	if o.Crop.Tolerance != AutoCropToleranceDefault || o.Crop.MinRetained != AutoCropMinRetainedDefault {
+		t.Errorf("expected the crop defaults, got %+v", o.Crop)
+	}
+
+	o, err = parseFlags(t, "--device", "kobo-libra2", "--device-quality", "70", "--split-tall", "--split-height", "1600", "--spreads", "rotate", "--auto-crop", "--auto-crop-min-retained", "0.8")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := Options{
+		Device:  Device{Width: 1264, Height: 1680, Grayscale: true, Quality: 70, Format: DeviceJPEG},
+		Split:   Split{Tall: true, Height: 1600},
+		Spreads: SpreadsRotate,
+		Crop:    Crop{Auto: true, Tolerance: AutoCropToleranceDefault, MinRetained: 0.8},
+	}
+	if o != want {
+		t.Errorf("got %+v, want %+v", o, want)
+	}
+}
+
+func TestFromFlagsErrors(t *testing.T) {
+	cases := [][]string{
+		{"--device", "kindle-fire"},
+		{"--device-width", "800"},
+		{"--split-height", "-1", "--split-tall"},
+		{"--split-height", "1600"},
+		{"--spreads", "fold"},
+		{"--spreads", "split", "--restitch"},
+		{"--auto-crop-tolerance", "300"},
+		{"--auto-crop-min-retained", "1.5"},
+	}
+	for _, args := range cases {
+		if _, err := parseFlags(t, args...); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+
+	// a flag missing, or of another type, is an error too rather than a zero
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddFlags(flags)
+	flags.Lookup("device-quality").Value = new(stringValue)
+	if _, err := FromFlags(flags); err == nil {
+		t.Error("expected an error for a flag of the wrong type")
+	}
+	if _, err := FromFlags(pflag.NewFlagSet("empty", pflag.ContinueOnError)); err == nil {
+		t.Error("expected an error for missing flags")
+	}
+}
+
+// stringValue is a pflag.Value of type string
+type stringValue string
+
+func (v *stringValue) String() string     { return string(*v) }
+func (v *stringValue) Set(s string) error { *v = stringValue(s); return nil }
+func (v *stringValue) Type() string       { return "string" }
@@ -0,0 +1,23 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package pageopts
+
+// Split is how long strips (webtoon pages thousands of pixels tall) are cut
+// into pages that fit a screen
+type Split struct {
+	// Tall slices the pages taller than Height into several, at the gutters
+	// between panels
+	Tall bool
+	// Restitch joins a chapter's pages into a single strip, sliced again into
+	// pages of Height, for the sites cutting their strips at random heights
+	Restitch bool
+	// Height is the height pages are sliced at, in pixels. 0 makes it each
+	// page's width times the device screen's aspect ratio (or times 1.5,
+	// without a device).
+	Height int
+}
+
+// Enabled reports whether s cuts pages at all
+func (s Split) Enabled() bool {
+	return s.Tall || s.Restitch
+}
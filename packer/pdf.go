@@ -0,0 +1,355 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	fcolor "github.com/fatih/color"
+)
+
+// pdfWriter writes a PDF file a page at a time, keeping track of the objects'
+// offsets for the cross-reference table written last
+type pdfWriter struct {
+	f *os.File
+	w *bufio.Writer
+	// n is the number of bytes written so far
+	n int
+	// offsets are the objects' offsets, by object number (0 is unused)
+	offsets []int
+	// catalog and pages are the numbers of the objects written last, once
+	// every page is known
+	catalog, pages int
+	// kids are the references to the pages written so far
+	kids []string
+	// bookmarks are the first pages of each bundled chapter so far
+	bookmarks []pdfBookmark
+	folder    string
+	// width and height are the size of the last page image added
+	width, height int
+	// images is the number of page images added, not counting the blank
+	// pages standing in for the ones that couldn't be
+	images int
+}
+
+// pdfBlankWidth and pdfBlankHeight are the size of the blank pages standing
+// in for a chapter's first pages, when those couldn't be added
+const (
+	pdfBlankWidth  = 800
+	pdfBlankHeight = 1200
+)
+
+// pdfBookmark is an outline entry, pointing at a chapter's first page
+type pdfBookmark struct {
+	// folder is the chapter's folder in the bundle
+	folder string
+	// page is the page's object number
+	page int
+}
+
+// write writes formatted output, counting its bytes
+func (pw *pdfWriter) write(format string, args ...any) {
+	n, _ := fmt.Fprintf(pw.w, format, args...)
+	pw.n += n
+}
+
+// reserve returns the number of a new object, to be written later
+func (pw *pdfWriter) reserve() int {
+	pw.offsets = append(pw.offsets, 0)
+	return len(pw.offsets) - 1
+}
+
+// object writes the object number id, made of the dictionary dict
+func (pw *pdfWriter) object(id int, dict string) {
+	pw.offsets[id] = pw.n
+	pw.write("%d 0 obj\n%s\nendobj\n", id, dict)
+}
+
+// stream writes the object number id, a stream of data described by dict
+// (with no /Length, which is added here)
+func (pw *pdfWriter) stream(id int, dict string, data []byte) {
+	pw.offsets[id] = pw.n
+	pw.write("%d 0 obj\n<< %s /Length %d >>\nstream\n", id, dict, len(data))
+	n, _ := pw.w.Write(data)
+	pw.n += n
+	pw.write("\nendstream\nendobj\n")
+}
+
+// ArchivePDF archives the given named files into a PDF file, a page per image
+// sized to the image. JPEG pages are embedded as they are, any other format
+// is converted to JPEG first. Bundles get an outline (bookmarks) entry per
+// chapter, by the folder their pages are in.
+func ArchivePDF(filename string, meta Metadata, files []File, progress func(page, progress int)) error {
+	return writePages(FormatPDF, filename, meta, files, progress)
+}
+
+func newPDFWriter(filename string) (*pdfWriter, error) {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return nil, err
+	}
+
+	pw := &pdfWriter{f: f, w: bufio.NewWriter(f), offsets: []int{0}, folder: "\x00"}
+	// the binary comment tells transfer tools the file isn't text
+	pw.write("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+	pw.catalog, pw.pages = pw.reserve(), pw.reserve()
+
+	return pw, nil
+}
+
+func (pw *pdfWriter) add(file File) error {
+	page := pw.reserve()
+	img, err := newPDFImage(file)
+	if err != nil {
+		// same as namePages: a single bad page shouldn't lose the whole
+		// chapter (or the whole bundle), nor shift the pages after it
+		fcolor.Yellow("- warning: page %s: %s (leaving a blank page in its place)", file.Name, err)
+		pw.blank(page)
+	} else {
+		content, xobject := pw.reserve(), pw.reserve()
+		pw.stream(xobject, img.dict(), img.data)
+		pw.stream(content, "", []byte(fmt.Sprintf("q %d 0 0 %d 0 0 cm /Im0 Do Q", img.width, img.height)))
+		pw.object(page, fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Resources << /XObject << /Im0 %d 0 R >> >> /Contents %d 0 R >>",
+			pw.pages, img.width, img.height, xobject, content,
+		))
+		pw.width, pw.height = img.width, img.height
+		pw.images++
+	}
+	pw.kids = append(pw.kids, fmt.Sprintf("%d 0 R", page))
+
+	if dir, _, bundled := strings.Cut(file.Name, "/"); bundled && dir != pw.folder {
+		pw.folder = dir
+		pw.bookmarks = append(pw.bookmarks, pdfBookmark{folder: dir, page: page})
+	}
+
+	return nil
+}
+
+// blank writes the page number id as a blank page, standing in for a page
+// that couldn't be added, sized as the page before it
+func (pw *pdfWriter) blank(id int) {
+	width, height := pw.width, pw.height
+	if width == 0 {
+		width, height = pdfBlankWidth, pdfBlankHeight
+	}
+	pw.object(id, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] >>", pw.pages, width, height))
+}
+
+func (pw *pdfWriter) close(meta Metadata) error {
+	defer pw.f.Close()
+
+	if pw.images == 0 {
+		return errors.New("no page could be added to the pdf")
+	}
+	pw.object(pw.pages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(pw.kids, " "), len(pw.kids)))
+
+	labels := map[string]string{}
+	for _, chapter := range meta.Chapters {
+		labels[chapter.Folder] = chapterLabel(chapter.Number, chapter.Title)
+	}
+
+	extra := ""
+	if len(pw.bookmarks) > 0 {
+		outlines := pw.reserve()
+		items := make([]int, len(pw.bookmarks))
+		for i := range pw.bookmarks {
+			items[i] = pw.reserve()
+		}
+		for i, b := range pw.bookmarks {
+			label, ok := labels[b.folder]
+			if !ok {
+				label = b.folder
+			}
+			dict := fmt.Sprintf("<< /Title %s /Parent %d 0 R /Dest [%d 0 R /Fit]", pdfText(label), outlines, b.page)
+			if i > 0 {
+				dict += fmt.Sprintf(" /Prev %d 0 R", items[i-1])
+			}
+			if i < len(items)-1 {
+				dict += fmt.Sprintf(" /Next %d 0 R", items[i+1])
+			}
+			pw.object(items[i], dict+" >>")
+		}
+		pw.object(outlines, fmt.Sprintf("<< /Type /Outlines /First %d 0 R /Last %d 0 R /Count %d >>", items[0], items[len(items)-1], len(items)))
+		extra += fmt.Sprintf(" /Outlines %d 0 R /PageMode /UseOutlines", outlines)
+	}
+	if meta.Direction != DirectionLTR {
+		extra += " /ViewerPreferences << /Direction /R2L >>"
+	}
+	pw.object(pw.catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R%s >>", pw.pages, extra))
+
+	title := strings.TrimSpace(meta.Series + " " + meta.Number)
+	if meta.Title != "" {
+		title += " - " + meta.Title
+	}
+	info := pw.reserve()
+	pw.object(info, fmt.Sprintf("<< /Title %s /Producer %s >>", pdfText(title), pdfText("manga-downloader")))
+
+	xref := pw.n
+	pw.write("xref\n0 %d\n0000000000 65535 f \n", len(pw.offsets))
+	for _, offset := range pw.offsets[1:] {
+		pw.write("%010d 00000 n \n", offset)
+	}
+	pw.write("trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(pw.offsets), pw.catalog, info, xref)
+
+	return pw.w.Flush()
+}
+
+// pdfImage is a page image, ready to be embedded as a DCT (JPEG) stream
+type pdfImage struct {
+	data          []byte
+	width, height int
+	colorSpace    string
+}
+
+// newPDFImage returns the JPEG stream of a page, converting it first when
+// it's in any other format
+func newPDFImage(file File) (*pdfImage, error) {
+	data := file.Data
+	if ext := strings.TrimPrefix(path.Ext(file.Name), "."); ext != "jpg" {
+		converted, err := convertToJPEG(data)
+		if err != nil {
+			return nil, fmt.Errorf("converting %s to jpeg: %w", ext, err)
+		}
+		data = converted
+	}
+
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("reading jpeg: %w", err)
+	}
+
+	img := &pdfImage{data: data, width: cfg.Width, height: cfg.Height, colorSpace: "/DeviceRGB"}
+	switch cfg.ColorModel {
+	case color.GrayModel:
+		img.colorSpace = "/DeviceGray"
+	case color.CMYKModel:
+		img.colorSpace = "/DeviceCMYK"
+	}
+
+	return img, nil
+}
+
+// dict returns the dictionary of the image's XObject stream
+func (img *pdfImage) dict() string {
+	dict := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter /DCTDecode",
+		img.width, img.height, img.colorSpace)
+	if img.colorSpace == "/DeviceCMYK" {
+		// CMYK JPEGs are written inverted (the Adobe convention image/jpeg
+		// follows too)
+		dict += " /Decode [1 0 1 0 1 0 1 0]"
+	}
+
+	return dict
+}
+
+// pdfText returns s as a PDF text string: UTF-16BE, hex encoded, so any
+// title can be written without escaping
+func pdfText(s string) string {
+	buf := &strings.Builder{}
+	buf.WriteString("<FEFF")
+	for _, r := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(buf, "%04X", r)
+	}
+	buf.WriteString(">")
+
+	return buf.String()
+}
+
+var (
+	pdfTrailerRe = regexp.MustCompile(`/Root (\d+) 0 R[^>]*>>\s*startxref\s+(\d+)`)
+	pdfPagesRe   = regexp.MustCompile(`/Pages (\d+) 0 R`)
+	pdfCountRe   = regexp.MustCompile(`/Count (\d+)`)
+)
+
+// countPDFPages returns the number of pages in the PDF at path, as the /Count
+// of its page tree, reached through the trailer and the cross-reference table
+// rather than reading the whole file (see CountPages)
+func countPDFPages(path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	tail := make([]byte, min(info.Size(), 1024))
+	if _, err := f.ReadAt(tail, info.Size()-int64(len(tail))); err != nil {
+		return 0, err
+	}
+	trailer := pdfTrailerRe.FindSubmatch(tail)
+	if trailer == nil {
+		return 0, errors.New("pdf trailer not found")
+	}
+	xref, _ := strconv.ParseInt(string(trailer[2]), 10, 64)
+
+	// object reads the object number id, found at the offset the
+	// cross-reference table (a 20 bytes entry per object) gives
+	object := func(id []byte) (string, error) {
+		n, _ := strconv.ParseInt(string(id), 10, 64)
+		r := bufio.NewReader(io.NewSectionReader(f, xref, info.Size()-xref))
+		for range 2 {
+			// the "xref" and the "0 <size>" subsection header lines
+			if _, err := r.ReadString('\n'); err != nil {
+				return "", err
+			}
+		}
+		if _, err := r.Discard(int(n) * 20); err != nil {
+			return "", err
+		}
+		entry := make([]byte, 20)
+		if _, err := io.ReadFull(r, entry); err != nil {
+			return "", err
+		}
+		offset, err := strconv.ParseInt(string(entry[:10]), 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid pdf cross-reference entry %q", entry)
+		}
+
+		r = bufio.NewReader(io.NewSectionReader(f, offset, info.Size()-offset))
+		obj := &strings.Builder{}
+		for !strings.HasSuffix(obj.String(), "endobj\n") {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return "", err
+			}
+			obj.WriteString(line)
+		}
+
+		return obj.String(), nil
+	}
+
+	catalog, err := object(trailer[1])
+	if err != nil {
+		return 0, fmt.Errorf("reading the pdf catalog: %w", err)
+	}
+	pages := pdfPagesRe.FindStringSubmatch(catalog)
+	if pages == nil {
+		return 0, errors.New("pdf page tree not found")
+	}
+	tree, err := object([]byte(pages[1]))
+	if err != nil {
+		return 0, fmt.Errorf("reading the pdf page tree: %w", err)
+	}
+	count := pdfCountRe.FindStringSubmatch(tree)
+	if count == nil {
+		return 0, errors.New("pdf page count not found")
+	}
+
+	return strconv.ParseInt(count[1], 10, 64)
+}
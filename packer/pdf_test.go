@@ -0,0 +1,139 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestArchivePDFNoFiles(t *testing.T) {
+	err := ArchivePDF(filepath.Join(t.TempDir(), "empty.pdf"), Metadata{}, []File{}, func(page, progress int) {})
+	if err == nil {
+		t.Fatal("expected an error when archiving zero files, got nil")
+	}
+}
+
+func TestArchivePDFBundle(t *testing.T) {
+	jpg := jpegBytes(t, gradientImage(16, 12))
+	files := []File{
+		{Name: "Chapter 0001/000.jpg", Data: jpg},
+		{Name: "Chapter 0001/001.png", Data: pngBytes(t, gradientImage(8, 20))},
+		// undecodable pages are left blank, sized as the page before
+		{Name: "Chapter 0002/000.jpg", Data: []byte("not an image")},
+		{Name: "Chapter 0002/001.jpg", Data: jpg},
+	}
+	meta := Metadata{
+		Series: "Series",
+		Number: "1-2",
+		Chapters: []ChapterMetadata{
+			{Folder: "Chapter 0001", Number: 1, Title: "Start"},
+			{Folder: "Chapter 0002", Number: 2},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "bundle.pdf")
+	pages := 0
+	if err := ArchivePDF(path, meta, files, func(page, progress int) { pages += page }); err != nil {
+		t.Fatalf("ArchivePDF: %v", err)
+	}
+	if pages != len(files) {
+		t.Errorf("expected progress for every file, got %d", pages)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Errorf("expected a PDF header and trailer")
+	}
+	// JPEG pages are embedded as they are
+	if bytes.Count(data, jpg) != 2 {
+		t.Errorf("expected both jpeg pages embedded untouched")
+	}
+	for _, want := range []string{
+		"/Count 4 >>",
+		"/MediaBox [0 0 16 12]",
+		"/MediaBox [0 0 8 20] >>",
+		"/MediaBox [0 0 8 20] /Resources",
+		"/Filter /DCTDecode",
+		"/PageMode /UseOutlines",
+		"/Direction /R2L",
+		"/Title " + pdfText("Chapter 1: Start"),
+		"/Title " + pdfText("Chapter 2"),
+	} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("expected the pdf to contain %q", want)
+		}
+	}
+
+	// every cross-reference entry points at its object
+	start := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+	if start == nil {
+		t.Fatal("expected a startxref")
+	}
+	xref, _ := strconv.Atoi(string(start[1]))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[xref:], -1)
+	if len(entries) == 0 {
+		t.Fatal("expected cross-reference entries")
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(data[offset:], []byte(want)) {
+			t.Errorf("expected object %d at offset %d", i+1, offset)
+		}
+	}
+}
+
+func TestArchivePDFSingleChapter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "single.pdf")
+	meta := Metadata{Series: "Series", Number: "1", Title: "One", Direction: DirectionLTR}
+	files := []File{{Name: "000.jpg", Data: jpegBytes(t, gradientImage(4, 4))}}
+	if err := ArchivePDF(path, meta, files, func(page, progress int) {}); err != nil {
+		t.Fatalf("ArchivePDF: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+
+	if bytes.Contains(data, []byte("/Outlines")) || bytes.Contains(data, []byte("/R2L")) {
+		t.Errorf("expected neither bookmarks nor right to left reading for a single ltr chapter")
+	}
+	if !bytes.Contains(data, []byte("/Title "+pdfText("Series 1 - One"))) {
+		t.Errorf("expected the document title in the info dictionary")
+	}
+}
+
+func TestArchivePDFBlankPages(t *testing.T) {
+	dir := t.TempDir()
+	bad := File{Name: "000.jpg", Data: []byte("not an image")}
+
+	path := filepath.Join(dir, "first.pdf")
+	files := []File{bad, {Name: "001.jpg", Data: jpegBytes(t, gradientImage(4, 4))}}
+	if err := ArchivePDF(path, Metadata{}, files, func(page, progress int) {}); err != nil {
+		t.Fatalf("ArchivePDF: %v", err)
+	}
+	// with no page before it, the blank one gets the default size
+	data, _ := os.ReadFile(path)
+	if want := fmt.Sprintf("/MediaBox [0 0 %d %d] >>", pdfBlankWidth, pdfBlankHeight); !bytes.Contains(data, []byte(want)) {
+		t.Errorf("expected the pdf to contain %q", want)
+	}
+	if count, err := countPDFPages(path); err != nil || count != 2 {
+		t.Errorf("expected 2 pages, got %d (%v)", count, err)
+	}
+
+	// a pdf of blank pages only is no pdf
+	if err := ArchivePDF(filepath.Join(dir, "blank.pdf"), Metadata{}, []File{bad}, func(page, progress int) {}); err == nil {
+		t.Error("expected an error when no page could be added")
+	}
+}
+
+func TestPdfText(t *testing.T) {
+	if got := pdfText("Aé"); got != "<FEFF004100E9>" {
+		t.Errorf("got %s", got)
+	}
+}
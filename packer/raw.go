@@ -3,7 +3,6 @@
 package packer
 
 import (
-	"errors"
 	"os"
 	"path/filepath"
 
@@ -14,30 +13,37 @@ import (
 // one file per page, honoring directory prefixes in entry names (bundles).
 // dirname is created fresh with os.Mkdir (not MkdirAll), so an
 // already-existing directory returns an os.IsExist-compatible error,
-// matching ArchiveCBZ's behaviour and letting the same version-bump loop in
-// pack() handle the dedup.
+// matching ArchiveCBZ's behaviour instead of mixing pages into it.
 func SaveRaw(dirname string, files []File, progress func(page, progress int)) error {
-	if len(files) == 0 {
-		return errors.New("no files to pack")
-	}
+	return writePages(FormatRaw, dirname, Metadata{}, files, progress)
+}
 
+// rawWriter saves pages as plain image files inside a folder
+type rawWriter struct {
+	dirname string
+}
+
+func newRawWriter(dirname string) (*rawWriter, error) {
 	if err := os.Mkdir(dirname, 0755); err != nil {
-		return err
+		return nil, err
 	}
 
-	for _, file := range files {
-		path := filepath.Join(dirname, filepath.FromSlash(file.Name))
-		if dir := filepath.Dir(path); dir != dirname {
-			if err := os.MkdirAll(dir, 0755); err != nil {
-				return err
-			}
-		}
-		if err := os.WriteFile(path, file.Data, 0644); err != nil {
+	return &rawWriter{dirname: dirname}, nil
+}
+
+func (rw *rawWriter) add(file File) error {
+	path := filepath.Join(rw.dirname, filepath.FromSlash(file.Name))
+	if dir := filepath.Dir(path); dir != rw.dirname {
+		if err := os.MkdirAll(dir, 0755); err != nil {
 			return err
 		}
-		progress(1, 0) // Report progress by single page increments
 	}
 
+	return os.WriteFile(path, file.Data, 0644)
+}
+
+// close has nothing left to write: raw folders carry no metadata
+func (rw *rawWriter) close(Metadata) error {
 	return nil
 }
 
@@ -0,0 +1,150 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+
+	"github.com/elboletaire/manga-downloader/downloader"
+	"github.com/fatih/color"
+	"golang.org/x/image/draw"
+)
+
+// restitch joins a chapter's pages into a single strip, top to bottom, and
+// slices it again at the gutters into pages of the split height (see
+// splitTall), so a chapter cut at random heights reads as a continuous strip
+// with clean page breaks. Pages of another width are scaled to the chapter's
+// usual one.
+//
+// The strip is streamed: pages are sliced off its top as soon as there's
+// enough of it, so no more than a source page and a couple of sliced ones are
+// ever held decoded, however long the chapter. A page that can't be decoded
+// ends the strip before it, and is kept as the site served it. A slice that
+// can't be encoded fails the whole chapter instead: it spans several pages,
+// so there's no original to keep in its place, and leaving it out would
+// leave a hole in the strip.
+func (t pageTransforms) restitch(pages []*downloader.File) ([]*downloader.File, error) {
+	width := commonWidth(pages)
+	if width == 0 {
+		return pages, nil
+	}
+	height := t.splitHeight(width)
+
+	restitched := []*downloader.File{}
+	emit := func(img image.Image, source []byte) error {
+		// the pages are cropped once sliced, as cropping them before would
+		// take the gutters between them out of the strip
+		if t.Crop.Enabled() {
+			img = cropMargins(img, t.Crop)
+		}
+		data, err := t.encode(img, source)
+		if err != nil {
+			return fmt.Errorf("page %03d: restitching it: %w", len(restitched)+1, err)
+		}
+		restitched = append(restitched, &downloader.File{Data: data, Page: uint(len(restitched) + 1)})
+		return nil
+	}
+
+	// strip holds the rows not sliced yet; source is the first page they
+	// came from, whose format the slices are encoded in
+	var strip *image.RGBA
+	var source []byte
+	flush := func() error {
+		if strip != nil {
+			for _, img := range splitTall(strip, height) {
+				if err := emit(img, source); err != nil {
+					return err
+				}
+			}
+		}
+		strip = nil
+		return nil
+	}
+
+	for i, page := range pages {
+		img, err := decodeImage(page.Data)
+		if err != nil {
+			color.Yellow("- warning: page %03d: decoding image: %s (keeping the original)", i, err)
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			restitched = append(restitched, page)
+			continue
+		}
+		if strip == nil {
+			source = page.Data
+		}
+		strip = appendRows(strip, img, width)
+
+		// two pages' worth is kept back, so splitTall can cut the end of the
+		// strip even, without leaving a sliver behind
+		for strip.Bounds().Dy() >= height*2 {
+			cut := findGutter(strip, height-height/3, height)
+			if err := emit(strip.SubImage(image.Rect(0, 0, width, cut)), source); err != nil {
+				return nil, err
+			}
+			strip = rowsFrom(strip, cut)
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return restitched, nil
+}
+
+// commonWidth returns the width most of pages have, 0 when none can be
+// decoded
+func commonWidth(pages []*downloader.File) int {
+	counts := map[int]int{}
+	width := 0
+	for _, page := range pages {
+		config, _, err := image.DecodeConfig(bytes.NewReader(page.Data))
+		if err != nil {
+			continue
+		}
+		counts[config.Width]++
+		if counts[config.Width] > counts[width] {
+			width = config.Width
+		}
+	}
+
+	return width
+}
+
+// appendRows returns strip with img added at its bottom, scaled to width.
+// Transparent pixels are flattened onto white, as convertToJPEG does.
+func appendRows(strip *image.RGBA, img image.Image, width int) *image.RGBA {
+	bounds := img.Bounds()
+	height := max(1, bounds.Dy()*width/bounds.Dx())
+	top := 0
+	if strip != nil {
+		top = strip.Bounds().Dy()
+	}
+
+	joined := image.NewRGBA(image.Rect(0, 0, width, top+height))
+	if strip != nil {
+		draw.Draw(joined, strip.Bounds(), strip, image.Point{}, draw.Src)
+	}
+	dst := image.Rect(0, top, width, top+height)
+	draw.Draw(joined, dst, image.White, image.Point{}, draw.Src)
+	if bounds.Dx() == width {
+		draw.Draw(joined, dst, img, bounds.Min, draw.Over)
+	} else {
+		draw.CatmullRom.Scale(joined, dst, img, bounds, draw.Over, nil)
+	}
+
+	return joined
+}
+
+// rowsFrom returns a copy of strip's rows from y on, so the rows before can be
+// let go
+func rowsFrom(strip *image.RGBA, y int) *image.RGBA {
+	bounds := strip.Bounds()
+	rest := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()-y))
+	draw.Draw(rest, rest.Bounds(), strip, image.Pt(0, y), draw.Src)
+
+	return rest
+}
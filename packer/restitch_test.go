@@ -0,0 +1,93 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/elboletaire/manga-downloader/downloader"
+	"github.com/elboletaire/manga-downloader/packer/pageopts"
+)
+
+// decodedHeights decodes pages, returning their heights, and failing on any
+// other width than width
+func decodedHeights(t *testing.T, pages []*downloader.File, width int) []int {
+	t.Helper()
+	hs := []int{}
+	for _, page := range pages {
+		img, err := png.Decode(bytes.NewReader(page.Data))
+		if err != nil {
+			t.Fatalf("expected png pages: %s", err)
+		}
+		if img.Bounds().Dx() != width {
+			t.Errorf("expected pages %d wide, got %d", width, img.Bounds().Dx())
+		}
+		hs = append(hs, img.Bounds().Dy())
+	}
+	return hs
+}
+
+func TestRestitch(t *testing.T) {
+	// a strip cut at random heights, panels straddling the cuts: 30, 250 and
+	// 120 rows, with gutters at 90-110 and 200-220 of the whole strip
+	strip := stripImage(32, 400, [2]int{0, 90}, [2]int{110, 200}, [2]int{220, 400})
+	pages := []*downloader.File{}
+	for _, r := range []image.Rectangle{image.Rect(0, 0, 32, 30), image.Rect(0, 30, 32, 280), image.Rect(0, 280, 32, 400)} {
+		pages = append(pages, &downloader.File{Data: pngBytes(t, strip.SubImage(r))})
+	}
+
+	restitch := pageTransforms{Options: pageopts.Options{Split: pageopts.Split{Restitch: true, Height: 120}}}
+	got := decodedHeights(t, applied(t, restitch, pages), 32)
+	// the first two cuts land on the gutters, the rest of the strip is cut
+	// even
+	want := []int{100, 110, 95, 95}
+	if len(got) != len(want) {
+		t.Fatalf("expected pages %v tall, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected pages %v tall, got %v", want, got)
+		}
+	}
+}
+
+func TestRestitchScalesToTheCommonWidth(t *testing.T) {
+	pages := []*downloader.File{
+		{Data: pngBytes(t, stripImage(32, 50, [2]int{0, 50}))},
+		// twice as wide: scaled to 32x25
+		{Data: pngBytes(t, stripImage(64, 50, [2]int{0, 50}))},
+		{Data: []byte("not an image")},
+		{Data: pngBytes(t, stripImage(32, 40, [2]int{0, 40}))},
+	}
+
+	restitch := pageTransforms{Options: pageopts.Options{Split: pageopts.Split{Restitch: true, Height: 100}}}
+	got := applied(t, restitch, pages)
+	if len(got) != 3 {
+		t.Fatalf("expected the strip before the broken page, the page and the strip after, got %d pages", len(got))
+	}
+	if hs := decodedHeights(t, []*downloader.File{got[0], got[2]}, 32); hs[0] != 75 || hs[1] != 40 {
+		t.Errorf("expected strips 75 and 40 tall, got %v", hs)
+	}
+	if string(got[1].Data) != "not an image" {
+		t.Error("expected the undecodable page kept in its place")
+	}
+}
+
+// TestRestitchFailsOnSlices covers the slices that can't be encoded: they
+// span several pages, so there's no original to keep, and the chapter fails
+// rather than being saved with a hole in it
+func TestRestitchFailsOnSlices(t *testing.T) {
+	// jpeg can't encode pages wider than 65535 pixels
+	pages := []*downloader.File{{Data: pngBytes(t, image.NewGray(image.Rect(0, 0, 70000, 30)))}}
+	restitch := pageTransforms{Options: pageopts.Options{
+		Split:  pageopts.Split{Restitch: true, Height: 10},
+		Device: pageopts.Device{Format: pageopts.DeviceJPEG},
+	}}
+
+	if got, err := restitch.apply(pages); err == nil {
+		t.Errorf("expected an error, got %d pages", len(got))
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/elboletaire/manga-downloader/grabber"
+)
+
+// SeriesFilename is the name of the series-level metadata file, in Mylar's
+// series.json format, read by Komga, Kavita and Mylar itself
+const SeriesFilename = "series.json"
+
+// seriesJSONVersion is the version of Mylar's series.json format written
+const seriesJSONVersion = "1.0.2"
+
+// SaveSeriesJSON writes meta into the SeriesFilename of outputdir, the series'
+// own folder. An existing file is updated rather than replaced: the fields
+// meta tells overwrite the file's, and the rest (say, a hand-set comicid) are
+// kept.
+//
+// Besides Mylar's own fields, the metadata carries the ones its format has no
+// place for: alternate_titles, authors, language and url.
+func SaveSeriesJSON(outputdir string, meta *grabber.SeriesMetadata) error {
+	path := filepath.Join(outputdir, SeriesFilename)
+	doc := map[string]any{}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if err = json.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+	}
+
+	metadata, _ := doc["metadata"].(map[string]any)
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	for key, value := range seriesFields(meta) {
+		metadata[key] = value
+	}
+	if _, ok := metadata["type"]; !ok {
+		metadata["type"] = "comicSeries"
+	}
+	doc["metadata"] = metadata
+	if _, ok := doc["version"]; !ok {
+		doc["version"] = seriesJSONVersion
+	}
+
+	if data, err = json.MarshalIndent(doc, "", "  "); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(outputdir, SeriesFilename+".*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// seriesFields returns the series.json metadata fields meta tells
+func seriesFields(meta *grabber.SeriesMetadata) map[string]any {
+	fields := map[string]any{}
+	set := func(key string, value any, ok bool) {
+		if ok {
+			fields[key] = value
+		}
+	}
+
+	set("name", meta.Title, meta.Title != "")
+	set("description_text", meta.Description, meta.Description != "")
+	set("year", meta.Year, meta.Year > 0)
+	// Mylar only tells running series from finished ones
+	switch meta.Status {
+	case grabber.StatusOngoing, grabber.StatusHiatus:
+		fields["status"] = "Continuing"
+	case grabber.StatusCompleted, grabber.StatusCancelled:
+		fields["status"] = "Ended"
+	}
+	if u, err := url.Parse(meta.URL); err == nil && u.Host != "" {
+		// the site publishing the chapters, for lack of a better publisher
+		fields["publisher"] = u.Host
+	}
+
+	set("alternate_titles", meta.AltTitles, len(meta.AltTitles) > 0)
+	set("authors", meta.Authors, len(meta.Authors) > 0)
+	set("language", meta.Language, meta.Language != "")
+	set("url", meta.URL, meta.URL != "")
+
+	return fields
+}
@@ -0,0 +1,92 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elboletaire/manga-downloader/grabber"
+)
+
+// readSeriesJSON decodes the series.json in dir
+func readSeriesJSON(t *testing.T, dir string) map[string]any {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, SeriesFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := map[string]any{}
+	if err = json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestSaveSeriesJSON(t *testing.T) {
+	dir := t.TempDir()
+	meta := &grabber.SeriesMetadata{
+		Title:     "Black Clover",
+		AltTitles: []string{"ブラッククローバー"},
+		Authors:   []string{"Tabata Yuuki"},
+		Status:    grabber.StatusOngoing,
+		Year:      2015,
+		Language:  "en",
+		URL:       "https://mangadex.org/title/e7eabe96-aa17-476f-b431-2497d5e9d060",
+	}
+	if err := SaveSeriesJSON(dir, meta); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := readSeriesJSON(t, dir)
+	if doc["version"] != "1.0.2" {
+		t.Errorf("expected Mylar's format version, got %v", doc["version"])
+	}
+	metadata := doc["metadata"].(map[string]any)
+	want := map[string]any{
+		"type":      "comicSeries",
+		"name":      "Black Clover",
+		"status":    "Continuing",
+		"year":      float64(2015),
+		"publisher": "mangadex.org",
+		"language":  "en",
+		"url":       meta.URL,
+	}
+	for key, value := range want {
+		if metadata[key] != value {
+			t.Errorf("%s: expected %v, got %v", key, value, metadata[key])
+		}
+	}
+	if authors, _ := metadata["authors"].([]any); len(authors) != 1 || authors[0] != "Tabata Yuuki" {
+		t.Errorf("expected the authors, got %v", metadata["authors"])
+	}
+	if _, ok := metadata["description_text"]; ok {
+		t.Error("expected no description when the site tells none")
+	}
+}
+
+func TestSaveSeriesJSONUpdates(t *testing.T) {
+	dir := t.TempDir()
+	existing := `{"version": "1.0.1", "metadata": {"name": "Old name", "comicid": 12345, "status": "Continuing"}}`
+	if err := os.WriteFile(filepath.Join(dir, SeriesFilename), []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SaveSeriesJSON(dir, &grabber.SeriesMetadata{Title: "Guya", Status: grabber.StatusCompleted}); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := readSeriesJSON(t, dir)
+	metadata := doc["metadata"].(map[string]any)
+	if metadata["name"] != "Guya" || metadata["status"] != "Ended" {
+		t.Errorf("expected the site's details to win, got %v", metadata)
+	}
+	if metadata["comicid"] != float64(12345) || doc["version"] != "1.0.1" {
+		t.Errorf("expected the fields the site doesn't tell to be kept, got %v", doc)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected no temporary file left behind, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// gutterTolerance is how much the luminance of a row's pixels can vary for
+// the row to still count as a gutter: JPEG noise makes even a blank row
+// anything but perfectly flat
+const gutterTolerance = 24
+
+// splitTall slices img into pages height pixels tall, or a bit shorter: each
+// cut is made on the gutter (a row of uniform colour, between panels) closest
+// to the height, looking as far as a third of it back, so speech bubbles and
+// panels aren't cut through. The last page can be up to a quarter taller, and
+// the last two are cut about even, so a sliver isn't left over. Images short
+// enough are returned whole.
+func splitTall(img image.Image, height int) []image.Image {
+	bounds := img.Bounds()
+	if height <= 0 || bounds.Dy() <= height*5/4 {
+		return []image.Image{img}
+	}
+
+	pages := []image.Image{}
+	top := bounds.Min.Y
+	for bounds.Max.Y-top > height*5/4 {
+		target := height
+		if rest := bounds.Max.Y - top; rest < height*2 {
+			target = rest / 2
+		}
+		cut := findGutter(img, top+target-target/3, top+target)
+		pages = append(pages, subImage(img, image.Rect(bounds.Min.X, top, bounds.Max.X, cut)))
+		top = cut
+	}
+
+	return append(pages, subImage(img, image.Rect(bounds.Min.X, top, bounds.Max.X, bounds.Max.Y)))
+}
+
+// findGutter returns the row to cut img at, between from and to (both
+// included): the middle of the gutter closest to to or, when there's none,
+// the flattest row, the least likely to be lettering.
+func findGutter(img image.Image, from, to int) int {
+	best, flattest := to, 256
+	for y := to; y >= from; y-- {
+		spread := rowSpread(img, y)
+		if spread <= gutterTolerance {
+			// found one, cut through its middle (within the window)
+			end := y
+			for y > from && rowSpread(img, y-1) <= gutterTolerance {
+				y--
+			}
+			return (y + end + 1) / 2
+		}
+		if spread < flattest {
+			best, flattest = y, spread
+		}
+	}
+
+	return best
+}
+
+// rowSpread returns how much the luminance of the pixels of img's row y
+// varies, from 0 (a single colour) to 255
+func rowSpread(img image.Image, y int) int {
+	bounds := img.Bounds()
+	lo, hi := 255, 0
+	track := func(l uint8) {
+		lo, hi = min(lo, int(l)), max(hi, int(l))
+	}
+
+	switch img := img.(type) {
+	case *image.YCbCr:
+		// the luma plane is the luminance already
+		row := img.YOffset(bounds.Min.X, y)
+		for _, l := range img.Y[row : row+bounds.Dx()] {
+			track(l)
+		}
+	case *image.Gray:
+		row := img.PixOffset(bounds.Min.X, y)
+		for _, l := range img.Pix[row : row+bounds.Dx()] {
+			track(l)
+		}
+	default:
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			track(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+		}
+	}
+
+	return hi - lo
+}
+
+// subImage returns the part of img within r, sharing its pixels when the
+// image type allows (every one the decoders return does)
+func subImage(img image.Image, r image.Rectangle) image.Image {
+	if sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(r)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, r.Min, draw.Src)
+
+	return dst
+}
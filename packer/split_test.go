@@ -0,0 +1,122 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/elboletaire/manga-downloader/downloader"
+	"github.com/elboletaire/manga-downloader/packer/pageopts"
+)
+
+// stripImage builds a webtoon strip w pixels wide: white, with a busy
+// (gradient) panel over each of the given row ranges
+func stripImage(w, h int, panels ...[2]int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	for _, p := range panels {
+		for y := p[0]; y < p[1]; y++ {
+			for x := 0; x < w; x++ {
+				img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 8), G: uint8(y), B: 128, A: 255})
+			}
+		}
+	}
+	return img
+}
+
+// heights returns the heights of pages
+func heights(pages []image.Image) []int {
+	hs := []int{}
+	for _, p := range pages {
+		hs = append(hs, p.Bounds().Dy())
+	}
+	return hs
+}
+
+func TestSplitTallCutsAtGutters(t *testing.T) {
+	// gutters at 220-260 and 480-520: the cuts go through their middles,
+	// rather than at 300 and 600, through the panels. The 400 rows left are
+	// too many for a page, and cut in two.
+	img := stripImage(32, 900, [2]int{0, 220}, [2]int{260, 480}, [2]int{520, 900})
+	pages := splitTall(img, 300)
+
+	got := heights(pages)
+	want := []int{240, 260, 200, 200}
+	if len(got) != len(want) {
+		t.Fatalf("expected pages %v tall, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected pages %v tall, got %v", want, got)
+			break
+		}
+	}
+	if pages[1].Bounds().Min.Y != 240 {
+		t.Errorf("expected the pages to follow one another, got %v", pages[1].Bounds())
+	}
+}
+
+func TestSplitTallWithoutGutters(t *testing.T) {
+	// a single panel all along: no cut exceeds the height anyway
+	pages := splitTall(stripImage(32, 1000, [2]int{0, 1000}), 300)
+	total := 0
+	for _, h := range heights(pages) {
+		if h > 300*5/4 || h < 200 {
+			t.Errorf("expected pages about 300 tall, got %v", heights(pages))
+		}
+		total += h
+	}
+	if total != 1000 {
+		t.Errorf("expected every row kept, got %d", total)
+	}
+}
+
+func TestSplitTallKeepsShortPages(t *testing.T) {
+	img := stripImage(32, 360, [2]int{0, 360})
+	if pages := splitTall(img, 300); len(pages) != 1 || pages[0] != image.Image(img) {
+		t.Errorf("expected a page within a quarter of the height kept whole, got %v", heights(pages))
+	}
+}
+
+func TestPageTransformsSplit(t *testing.T) {
+	short := pngBytes(t, stripImage(32, 40, [2]int{0, 40}))
+	pages := []*downloader.File{
+		{Data: jpegBytes(t, stripImage(32, 200, [2]int{0, 90}, [2]int{110, 200})), Page: 1},
+		{Data: short, Page: 2},
+	}
+
+	split := pageTransforms{Options: pageopts.Options{Split: pageopts.Split{Tall: true, Height: 100}}}
+	got := applied(t, split, pages)
+	if len(got) != 3 {
+		t.Fatalf("expected the strip in 2 pages and the short one alone, got %d pages", len(got))
+	}
+	if extFromContent(got[0].Data) != "jpg" || got[1].Page != 1 {
+		t.Errorf("expected the slices to keep the page's format and number")
+	}
+	if &got[2].Data[0] != &short[0] {
+		t.Error("expected the short page untouched")
+	}
+
+	// without a height, pages are sliced to the device screen's aspect ratio
+	kobo := pageTransforms{Options: pageopts.Options{Split: pageopts.Split{Tall: true}, Device: pageopts.Device{Width: 100, Height: 150}}}
+	if h := kobo.splitHeight(32); h != 48 {
+		t.Errorf("expected a 48px height, got %d", h)
+	}
+	if h := split.splitHeight(32); h != 100 {
+		t.Errorf("expected the height set, got %d", h)
+	}
+}
+
+func TestPerSourcePage(t *testing.T) {
+	reported := 0
+	progress := perSourcePage(func(page, _ int) { reported += page }, 2, 5)
+	for range 5 {
+		progress(1, 0)
+	}
+	if reported != 2 {
+		t.Errorf("expected 2 pages reported, got %d", reported)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"image"
+
+	"github.com/elboletaire/manga-downloader/packer/pageopts"
+	"golang.org/x/image/draw"
+)
+
+// isSpread reports whether a page width by height pixels is a double-page
+// spread
+func isSpread(width, height int) bool {
+	return width > height
+}
+
+// spread reworks img as mode says when it's a spread, in reading order for
+// direction (DirectionRTL reading its right page first, DirectionLTR its
+// left one). Other pages are returned as they are.
+func spread(img image.Image, mode, direction string) []image.Image {
+	bounds := img.Bounds()
+	if !isSpread(bounds.Dx(), bounds.Dy()) {
+		return []image.Image{img}
+	}
+
+	mid := bounds.Min.X + bounds.Dx()/2
+	first := subImage(img, image.Rect(bounds.Min.X, bounds.Min.Y, mid, bounds.Max.Y))
+	second := subImage(img, image.Rect(mid, bounds.Min.Y, bounds.Max.X, bounds.Max.Y))
+	if direction != DirectionLTR {
+		first, second = second, first
+	}
+
+	switch mode {
+	case pageopts.SpreadsSplit:
+		return []image.Image{first, second}
+	case pageopts.SpreadsSplitAndKeep:
+		return []image.Image{img, first, second}
+	case pageopts.SpreadsRotate:
+		// the page read first ends up on top
+		return []image.Image{rotate(img, direction == DirectionLTR)}
+	default:
+		return []image.Image{img}
+	}
+}
+
+// rotate turns img a quarter, clockwise or counterclockwise
+func rotate(img image.Image, clockwise bool) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	src := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, h, w))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// (x, y) goes to column h-1-y when turning clockwise, to column y
+			// the other way around
+			dx, dy := h-1-y, x
+			if !clockwise {
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):][:4], src.Pix[src.PixOffset(x, y):][:4])
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,88 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/elboletaire/manga-downloader/downloader"
+	"github.com/elboletaire/manga-downloader/packer/pageopts"
+)
+
+var (
+	red  = color.RGBA{R: 255, A: 255}
+	blue = color.RGBA{B: 255, A: 255}
+)
+
+// spreadImage builds a 40x20 spread: its left page red, its right page blue
+func spreadImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	draw.Draw(img, image.Rect(0, 0, 20, 20), image.NewUniform(red), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(20, 0, 40, 20), image.NewUniform(blue), image.Point{}, draw.Src)
+	return img
+}
+
+// colorAt returns the color of img's pixel at (x, y), relative to its bounds
+func colorAt(img image.Image, x, y int) color.RGBA {
+	b := img.Bounds()
+	return color.RGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.RGBA)
+}
+
+func TestSpreadSplitInReadingOrder(t *testing.T) {
+	manga := spread(spreadImage(), pageopts.SpreadsSplit, DirectionRTL)
+	if len(manga) != 2 || colorAt(manga[0], 0, 0) != blue || colorAt(manga[1], 0, 0) != red {
+		t.Errorf("expected the right page first, for manga")
+	}
+	if size := manga[0].Bounds().Size(); size != image.Pt(20, 20) {
+		t.Errorf("expected 20x20 halves, got %v", size)
+	}
+
+	comic := spread(spreadImage(), pageopts.SpreadsSplit, DirectionLTR)
+	if len(comic) != 2 || colorAt(comic[0], 0, 0) != red || colorAt(comic[1], 0, 0) != blue {
+		t.Errorf("expected the left page first, for comics")
+	}
+}
+
+func TestSpreadSplitAndKeep(t *testing.T) {
+	img := spreadImage()
+	pages := spread(img, pageopts.SpreadsSplitAndKeep, DirectionRTL)
+	if len(pages) != 3 || pages[0] != image.Image(img) || colorAt(pages[1], 0, 0) != blue {
+		t.Errorf("expected the spread followed by its pages, got %d pages", len(pages))
+	}
+}
+
+func TestSpreadRotate(t *testing.T) {
+	// the page read first ends up on top
+	for direction, top := range map[string]color.RGBA{DirectionRTL: blue, DirectionLTR: red} {
+		pages := spread(spreadImage(), pageopts.SpreadsRotate, direction)
+		if len(pages) != 1 || pages[0].Bounds().Size() != image.Pt(20, 40) {
+			t.Fatalf("%s: expected a single 20x40 page", direction)
+		}
+		if got := colorAt(pages[0], 10, 5); got != top {
+			t.Errorf("%s: expected %v on top, got %v", direction, top, got)
+		}
+	}
+}
+
+func TestSpreadKeepsPortraitPages(t *testing.T) {
+	img := gradientImage(20, 30)
+	if pages := spread(img, pageopts.SpreadsSplit, DirectionRTL); len(pages) != 1 || pages[0] != image.Image(img) {
+		t.Error("expected a portrait page left alone")
+	}
+}
+
+func TestPageTransformsSpreads(t *testing.T) {
+	portrait := pngBytes(t, gradientImage(20, 30))
+	pages := []*downloader.File{{Data: pngBytes(t, spreadImage()), Page: 1}, {Data: portrait, Page: 2}}
+
+	got := applied(t, pageTransforms{Options: pageopts.Options{Spreads: pageopts.SpreadsSplit}, direction: DirectionRTL}, pages)
+	if len(got) != 3 || got[1].Page != 1 || &got[2].Data[0] != &portrait[0] {
+		t.Errorf("expected the spread split in two, the portrait page untouched, got %d pages", len(got))
+	}
+	if got := applied(t, pageTransforms{Options: pageopts.Options{Spreads: pageopts.SpreadsKeep}}, pages); len(got) != 2 {
+		t.Errorf("expected spreads kept, got %d pages", len(got))
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/elboletaire/manga-downloader/downloader"
+	"github.com/elboletaire/manga-downloader/grabber"
+	"github.com/elboletaire/manga-downloader/packer/pageopts"
+	"github.com/fatih/color"
+	"golang.org/x/image/draw"
+)
+
+// pageTransforms are the reworkings a chapter's pages go through before being
+// named (see namePages)
+type pageTransforms struct {
+	pageopts.Options
+	// direction is the reading direction spreads are read in
+	direction string
+}
+
+// newPageTransforms returns the page transforms of pages, read in s' reading
+// direction
+func newPageTransforms(s grabber.Site, pages pageopts.Options) pageTransforms {
+	return pageTransforms{Options: pages, direction: s.GetReadingDirection()}
+}
+
+// apply reworks pages, returning them as they are when there's nothing to do.
+// Like namePages' conversions, a page failing to be reworked is kept as the
+// site served it, with a warning, rather than losing its whole chapter; only
+// a restitched chapter, whose slices have no original, fails (see restitch).
+func (t pageTransforms) apply(pages []*downloader.File) ([]*downloader.File, error) {
+	if !t.Enabled() {
+		return pages, nil
+	}
+	if t.Split.Restitch {
+		// spreads can't be reworked along with it (see pageopts.FromFlags),
+		// and the slices are cropped as they're cut
+		return t.restitch(pages)
+	}
+
+	reworked := make([]*downloader.File, 0, len(pages))
+	for i, page := range pages {
+		files, err := t.rework(page)
+		if err != nil {
+			color.Yellow("- warning: page %03d: reworking it: %s (keeping the original)", i, err)
+			files = []*downloader.File{page}
+		}
+		reworked = append(reworked, files...)
+	}
+
+	return reworked, nil
+}
+
+// rework runs a page through the transforms: its margins cropped, its spreads
+// reworked, sliced when it's too tall, then fit for the device. A page none of
+// them changes is returned untouched, so it's not re-encoded for nothing.
+func (t pageTransforms) rework(page *downloader.File) ([]*downloader.File, error) {
+	if !t.Device.Enabled() && !t.Crop.Enabled() {
+		// the pages that are neither spreads nor too tall needn't even be
+		// decoded
+		config, _, err := image.DecodeConfig(bytes.NewReader(page.Data))
+		wide := t.ReworksSpreads() && isSpread(config.Width, config.Height)
+		if err == nil && !wide && config.Height <= t.maxHeight(config.Width) {
+			return []*downloader.File{page}, nil
+		}
+	}
+
+	decoded, err := decodeImage(page.Data)
+	if err != nil {
+		return nil, fmt.Errorf("decoding image: %w", err)
+	}
+	// spreads are told apart (and tall pages sliced) by the page without
+	// its margins
+	img := decoded
+	if t.Crop.Enabled() {
+		img = cropMargins(decoded, t.Crop)
+	}
+	imgs := []image.Image{img}
+	if t.ReworksSpreads() {
+		imgs = spread(img, t.Spreads, t.direction)
+	}
+	if t.Split.Tall {
+		sliced := []image.Image{}
+		for _, img := range imgs {
+			sliced = append(sliced, splitTall(img, t.splitHeight(img.Bounds().Dx()))...)
+		}
+		imgs = sliced
+	}
+	if len(imgs) == 1 && imgs[0] == decoded && !t.Device.Enabled() {
+		return []*downloader.File{page}, nil
+	}
+
+	files := make([]*downloader.File, 0, len(imgs))
+	for _, img := range imgs {
+		data, err := t.encode(img, page.Data)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, &downloader.File{Data: data, Page: page.Page})
+	}
+
+	return files, nil
+}
+
+// encode fits a reworked page for the device, and encodes it in the device's
+// format. Without a device, it's encoded in the format of the page it comes
+// from, source (PNG standing in for the formats that can't be encoded here),
+// at the quality convertToJPEG uses.
+func (t pageTransforms) encode(img image.Image, source []byte) ([]byte, error) {
+	if t.Device.Enabled() {
+		return encodePage(fitDevice(img, t.Device), t.Device.Format, t.Device.Quality)
+	}
+	if extFromContent(source) == "jpg" {
+		return encodePage(img, pageopts.DeviceJPEG, jpegQuality)
+	}
+
+	return encodePage(img, pageopts.DevicePNG, 0)
+}
+
+// splitHeight returns the height a page width pixels wide is sliced at
+func (t pageTransforms) splitHeight(width int) int {
+	switch {
+	case t.Split.Height > 0:
+		return t.Split.Height
+	case t.Device.Width > 0 && t.Device.Height > 0:
+		return width * t.Device.Height / t.Device.Width
+	default:
+		return width * 3 / 2
+	}
+}
+
+// maxHeight returns the height a page width pixels wide can have without
+// being sliced
+func (t pageTransforms) maxHeight(width int) int {
+	if !t.Split.Tall {
+		return int(^uint(0) >> 1)
+	}
+
+	return t.splitHeight(width) * 5 / 4
+}
+
+// fitDevice scales img down to fit the device's screen, keeping its aspect
+// ratio, and turns it gray when the device is. Pages are never scaled up:
+// that only makes them bigger, not sharper.
+func fitDevice(img image.Image, d pageopts.Device) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	scale := 1.0
+	if d.Width > 0 && w > d.Width {
+		scale = float64(d.Width) / float64(w)
+	}
+	if d.Height > 0 && h > d.Height {
+		scale = min(scale, float64(d.Height)/float64(h))
+	}
+	size := image.Rect(0, 0, max(1, int(float64(w)*scale+0.5)), max(1, int(float64(h)*scale+0.5)))
+
+	if scale == 1 && !d.Grayscale {
+		return img
+	}
+
+	// transparent pages are flattened onto white first, as convertToJPEG does
+	src := flattenAlpha(img)
+	var dst draw.Image = image.NewRGBA(size)
+	if d.Grayscale {
+		dst = image.NewGray(size)
+	}
+	if scale == 1 {
+		draw.Draw(dst, size, src, bounds.Min, draw.Src)
+	} else {
+		// CatmullRom keeps screentones and lettering crisp where the cheaper
+		// kernels blur (or alias) them
+		draw.CatmullRom.Scale(dst, size, src, bounds, draw.Src, nil)
+	}
+
+	return dst
+}
+
+// encodePage encodes img as format (pageopts.DeviceJPEG or pageopts.DevicePNG),
+// at quality when it's a JPEG (jpegQuality when 0)
+func encodePage(img image.Image, format string, quality int) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	switch format {
+	case pageopts.DevicePNG:
+		if err := png.Encode(buf, img); err != nil {
+			return nil, fmt.Errorf("encoding png: %w", err)
+		}
+	default:
+		if quality == 0 {
+			quality = jpegQuality
+		}
+		if err := jpeg.Encode(buf, flattenAlpha(img), &jpeg.Options{Quality: quality}); err != nil {
+			return nil, fmt.Errorf("encoding jpeg: %w", err)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// perSourcePage adapts progress, reported once per page written, to the
+// source pages the chapter was downloaded with (which the transforms can
+// slice into more, or restitch into fewer), so the progress bars sized after those still end up full
+func perSourcePage(progress func(page, progress int), source, written int) func(page, progress int) {
+	if source == written || written == 0 {
+		return progress
+	}
+
+	n, reported := 0, 0
+	return func(page, p int) {
+		n++
+		for ; reported < n*source/written; reported++ {
+			progress(1, p)
+		}
+	}
+}
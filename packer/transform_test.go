@@ -0,0 +1,92 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"testing"
+
+	"github.com/elboletaire/manga-downloader/downloader"
+	"github.com/elboletaire/manga-downloader/packer/pageopts"
+)
+
+// applied returns pages as tr reworks them, failing the test on an error
+func applied(t *testing.T, tr pageTransforms, pages []*downloader.File) []*downloader.File {
+	t.Helper()
+
+	got, err := tr.apply(pages)
+	if err != nil {
+		t.Fatalf("unexpected error reworking the pages: %s", err)
+	}
+
+	return got
+}
+
+func TestFitDevice(t *testing.T) {
+	cases := []struct {
+		name   string
+		size   image.Point
+		device pageopts.Device
+		want   image.Point
+	}{
+		{"taller than the screen", image.Pt(400, 1200), pageopts.Device{Width: 300, Height: 600}, image.Pt(200, 600)},
+		{"wider than the screen", image.Pt(1200, 400), pageopts.Device{Width: 300, Height: 600}, image.Pt(300, 100)},
+		{"smaller, never scaled up", image.Pt(100, 50), pageopts.Device{Width: 300, Height: 600}, image.Pt(100, 50)},
+		{"width limit only", image.Pt(600, 3000), pageopts.Device{Width: 300}, image.Pt(300, 1500)},
+	}
+
+	for _, c := range cases {
+		got := fitDevice(gradientImage(c.size.X, c.size.Y), c.device).Bounds().Size()
+		if got != c.want {
+			t.Errorf("%s: expected %v, got %v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestFitDeviceGrayscale(t *testing.T) {
+	img := fitDevice(gradientImage(8, 8), pageopts.Device{Grayscale: true})
+	if _, ok := img.(*image.Gray); !ok {
+		t.Errorf("expected a gray image, got %T", img)
+	}
+	if _, ok := fitDevice(gradientImage(8, 8), pageopts.Device{Width: 100}).(*image.Gray); ok {
+		t.Error("expected a color device to keep the colors")
+	}
+}
+
+func TestPageTransformsApply(t *testing.T) {
+	pages := []*downloader.File{
+		{Data: pngBytes(t, gradientImage(16, 32)), Page: 1},
+		{Data: []byte("not an image"), Page: 2},
+	}
+
+	// no device, no reworking: the very same pages come back
+	if got := applied(t, pageTransforms{}, pages); &got[0] != &pages[0] {
+		t.Error("expected the pages as they are without a device")
+	}
+
+	kobo := pageTransforms{Options: pageopts.Options{Device: pageopts.Device{Width: 8, Height: 8, Grayscale: true, Quality: 80, Format: pageopts.DeviceJPEG}}}
+	got := applied(t, kobo, pages)
+	if len(got) != 2 || got[0].Page != 1 {
+		t.Fatalf("expected both pages back, in order, got %d", len(got))
+	}
+	img, err := jpeg.Decode(bytes.NewReader(got[0].Data))
+	if err != nil {
+		t.Fatalf("expected a jpeg page: %s", err)
+	}
+	if size := img.Bounds().Size(); size != image.Pt(4, 8) {
+		t.Errorf("expected the page scaled to 4x8, got %v", size)
+	}
+	if _, ok := img.(*image.Gray); !ok {
+		t.Errorf("expected a grayscale jpeg, got %T", img)
+	}
+	if !bytes.Equal(got[1].Data, pages[1].Data) {
+		t.Error("expected the undecodable page kept as it was")
+	}
+
+	remarkable := pageTransforms{Options: pageopts.Options{Device: pageopts.DeviceProfiles["remarkable"]}}
+	if ext := extFromContent(applied(t, remarkable, pages[:1])[0].Data); ext != "png" {
+		t.Errorf("expected a png page, got %s", ext)
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright (C) 2023-2026 Òscar Casajuana Alonso
+
+package packer
+
+import "errors"
+
+// pageWriter writes a file (or raw folder) a page at a time, so its pages
+// don't all need to be held in memory at once (see BundleWriter). The
+// metadata only matters once every page is in, so it's given to close.
+type pageWriter interface {
+	// add writes the next page
+	add(file File) error
+	// close finishes the file, described by meta. It must be called even
+	// after add failed, to release the file.
+	close(meta Metadata) error
+}
+
+// newPageWriter creates path (which mustn't exist) to write its pages in
+// format, CBZ when it isn't one of Formats
+func newPageWriter(format, path string) (pageWriter, error) {
+	f, ok := LookupFormat(format)
+	if !ok {
+		f = Formats[0]
+	}
+
+	return f.newWriter(path)
+}
+
+// writePages saves files into a new file (or raw folder) at path, in format
+func writePages(format, path string, meta Metadata, files []File, progress func(page, progress int)) error {
+	if len(files) == 0 {
+		return errors.New("no files to pack")
+	}
+	w, err := newPageWriter(format, path)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if err := w.add(file); err != nil {
+			w.close(meta)
+			return err
+		}
+		progress(1, 0) // Report progress by single page increments
+	}
+
+	return w.close(meta)
+}
@@ -1,8 +1,8 @@
 // Copyright (C) 2023-2026 Òscar Casajuana Alonso
 
 // verify-cbz checks that the given .cbz files are real chapter archives:
-// they must exist, contain at least MinPages entries, and every entry must
-// be a non-empty image (JPG/PNG/GIF/WebP, checked by magic bytes) whose
+// they must exist, contain at least MinPages entries, and every entry (but
+// their ComicInfo.xml) must be a non-empty image (JPG/PNG/GIF/WebP, checked by magic bytes) whose
 // extension matches its actual content.
 //
 // AVIF entries are a failure by default: the downloader converts AVIF pages
@@ -68,7 +68,8 @@ func verify(path string) error {
 
 	images := 0
 	for _, f := range r.File {
-		if f.FileInfo().IsDir() {
+		// the metadata the downloader writes next to the pages
+		if f.FileInfo().IsDir() || f.Name == "ComicInfo.xml" {
 			continue
 		}
 		if f.UncompressedSize64 == 0 {